@@ -17,6 +17,7 @@ limitations under the License.
 package marathon
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -642,13 +643,18 @@ func (r *Application) String() string {
 
 // Applications retrieves an array of all the applications which are running in marathon
 func (r *marathonClient) Applications(v url.Values) (*Applications, error) {
+	return r.ApplicationsContext(context.Background(), v)
+}
+
+// ApplicationsContext is like Applications but uses the given context
+func (r *marathonClient) ApplicationsContext(ctx context.Context, v url.Values) (*Applications, error) {
 	query := v.Encode()
 	if query != "" {
 		query = "?" + query
 	}
 
 	applications := new(Applications)
-	err := r.apiGet(marathonAPIApps+query, nil, applications)
+	err := r.apiGet(ctx, marathonAPIApps+query, nil, applications)
 	if err != nil {
 		return nil, err
 	}
@@ -658,7 +664,12 @@ func (r *marathonClient) Applications(v url.Values) (*Applications, error) {
 
 // ListApplications retrieves an array of the application names currently running in marathon
 func (r *marathonClient) ListApplications(v url.Values) ([]string, error) {
-	applications, err := r.Applications(v)
+	return r.ListApplicationsContext(context.Background(), v)
+}
+
+// ListApplicationsContext is like ListApplications but uses the given context
+func (r *marathonClient) ListApplicationsContext(ctx context.Context, v url.Values) ([]string, error) {
+	applications, err := r.ApplicationsContext(ctx, v)
 	if err != nil {
 		return nil, err
 	}
@@ -674,8 +685,13 @@ func (r *marathonClient) ListApplications(v url.Values) ([]string, error) {
 // 		name: 		the id used to identify the application
 //		version: 	the version (normally a timestamp) your looking for
 func (r *marathonClient) HasApplicationVersion(name, version string) (bool, error) {
+	return r.HasApplicationVersionContext(context.Background(), name, version)
+}
+
+// HasApplicationVersionContext is like HasApplicationVersion but uses the given context
+func (r *marathonClient) HasApplicationVersionContext(ctx context.Context, name, version string) (bool, error) {
 	id := trimRootPath(name)
-	versions, err := r.ApplicationVersions(id)
+	versions, err := r.ApplicationVersionsContext(ctx, id)
 	if err != nil {
 		return false, err
 	}
@@ -686,9 +702,14 @@ func (r *marathonClient) HasApplicationVersion(name, version string) (bool, erro
 // ApplicationVersions is a list of versions which has been deployed with marathon for a specific application
 //		name:		the id used to identify the application
 func (r *marathonClient) ApplicationVersions(name string) (*ApplicationVersions, error) {
+	return r.ApplicationVersionsContext(context.Background(), name)
+}
+
+// ApplicationVersionsContext is like ApplicationVersions but uses the given context
+func (r *marathonClient) ApplicationVersionsContext(ctx context.Context, name string) (*ApplicationVersions, error) {
 	path := fmt.Sprintf("%s/versions", buildPath(name))
 	versions := new(ApplicationVersions)
-	if err := r.apiGet(path, nil, versions); err != nil {
+	if err := r.apiGet(ctx, path, nil, versions); err != nil {
 		return nil, err
 	}
 	return versions, nil
@@ -698,9 +719,14 @@ func (r *marathonClient) ApplicationVersions(name string) (*ApplicationVersions,
 // 		name: 		the id used to identify the application
 //		version: 	the version (normally a timestamp) you wish to change to
 func (r *marathonClient) SetApplicationVersion(name string, version *ApplicationVersion) (*DeploymentID, error) {
+	return r.SetApplicationVersionContext(context.Background(), name, version)
+}
+
+// SetApplicationVersionContext is like SetApplicationVersion but uses the given context
+func (r *marathonClient) SetApplicationVersionContext(ctx context.Context, name string, version *ApplicationVersion) (*DeploymentID, error) {
 	path := buildPath(name)
 	deploymentID := new(DeploymentID)
-	if err := r.apiPut(path, version, deploymentID); err != nil {
+	if err := r.apiPut(ctx, path, version, deploymentID); err != nil {
 		return nil, err
 	}
 
@@ -710,11 +736,16 @@ func (r *marathonClient) SetApplicationVersion(name string, version *Application
 // Application retrieves the application configuration from marathon
 // 		name: 		the id used to identify the application
 func (r *marathonClient) Application(name string) (*Application, error) {
+	return r.ApplicationContext(context.Background(), name)
+}
+
+// ApplicationContext is like Application but uses the given context
+func (r *marathonClient) ApplicationContext(ctx context.Context, name string) (*Application, error) {
 	var wrapper struct {
 		Application *Application `json:"app"`
 	}
 
-	if err := r.apiGet(buildPath(name), nil, &wrapper); err != nil {
+	if err := r.apiGet(ctx, buildPath(name), nil, &wrapper); err != nil {
 		return nil, err
 	}
 
@@ -725,6 +756,11 @@ func (r *marathonClient) Application(name string) (*Application, error) {
 // 		name: 		the id used to identify the application
 //		opts:		GetAppOpts request payload
 func (r *marathonClient) ApplicationBy(name string, opts *GetAppOpts) (*Application, error) {
+	return r.ApplicationByContext(context.Background(), name, opts)
+}
+
+// ApplicationByContext is like ApplicationBy but uses the given context
+func (r *marathonClient) ApplicationByContext(ctx context.Context, name string, opts *GetAppOpts) (*Application, error) {
 	path, err := addOptions(buildPath(name), opts)
 	if err != nil {
 		return nil, err
@@ -733,7 +769,7 @@ func (r *marathonClient) ApplicationBy(name string, opts *GetAppOpts) (*Applicat
 		Application *Application `json:"app"`
 	}
 
-	if err := r.apiGet(path, nil, &wrapper); err != nil {
+	if err := r.apiGet(ctx, path, nil, &wrapper); err != nil {
 		return nil, err
 	}
 
@@ -744,10 +780,15 @@ func (r *marathonClient) ApplicationBy(name string, opts *GetAppOpts) (*Applicat
 // 		name: 		the id used to identify the application
 // 		version:  the version of the configuration you would like to receive
 func (r *marathonClient) ApplicationByVersion(name, version string) (*Application, error) {
+	return r.ApplicationByVersionContext(context.Background(), name, version)
+}
+
+// ApplicationByVersionContext is like ApplicationByVersion but uses the given context
+func (r *marathonClient) ApplicationByVersionContext(ctx context.Context, name, version string) (*Application, error) {
 	app := new(Application)
 
 	path := fmt.Sprintf("%s/versions/%s", buildPath(name), version)
-	if err := r.apiGet(path, nil, app); err != nil {
+	if err := r.apiGet(ctx, path, nil, app); err != nil {
 		return nil, err
 	}
 
@@ -758,8 +799,13 @@ func (r *marathonClient) ApplicationByVersion(name, version string) (*Applicatio
 // If no health checks exist, we simply return true
 // 		name: 		the id used to identify the application
 func (r *marathonClient) ApplicationOK(name string) (bool, error) {
+	return r.ApplicationOKContext(context.Background(), name)
+}
+
+// ApplicationOKContext is like ApplicationOK but uses the given context
+func (r *marathonClient) ApplicationOKContext(ctx context.Context, name string) (bool, error) {
 	// step: get the application
-	application, err := r.Application(name)
+	application, err := r.ApplicationContext(ctx, name)
 	if err != nil {
 		return false, err
 	}
@@ -796,7 +842,12 @@ func (r *marathonClient) ApplicationOK(name string) (bool, error) {
 // ApplicationDeployments retrieves an array of Deployment IDs for an application
 //       name:       the id used to identify the application
 func (r *marathonClient) ApplicationDeployments(name string) ([]*DeploymentID, error) {
-	application, err := r.Application(name)
+	return r.ApplicationDeploymentsContext(context.Background(), name)
+}
+
+// ApplicationDeploymentsContext is like ApplicationDeployments but uses the given context
+func (r *marathonClient) ApplicationDeploymentsContext(ctx context.Context, name string) ([]*DeploymentID, error) {
+	application, err := r.ApplicationContext(ctx, name)
 	if err != nil {
 		return nil, err
 	}
@@ -807,8 +858,13 @@ func (r *marathonClient) ApplicationDeployments(name string) ([]*DeploymentID, e
 // CreateApplication creates a new application in Marathon
 // 		application:		the structure holding the application configuration
 func (r *marathonClient) CreateApplication(application *Application) (*Application, error) {
+	return r.CreateApplicationContext(context.Background(), application)
+}
+
+// CreateApplicationContext is like CreateApplication but uses the given context
+func (r *marathonClient) CreateApplicationContext(ctx context.Context, application *Application) (*Application, error) {
 	result := new(Application)
-	if err := r.ApiPost(marathonAPIApps, application, result); err != nil {
+	if err := r.apiPost(ctx, marathonAPIApps, application, result); err != nil {
 		return nil, err
 	}
 
@@ -819,28 +875,46 @@ func (r *marathonClient) CreateApplication(application *Application) (*Applicati
 //		name:		the id of the application
 //		timeout:	a duration of time to wait for an application to deploy
 func (r *marathonClient) WaitOnApplication(name string, timeout time.Duration) error {
-	return r.wait(name, timeout, r.appExistAndRunning)
+	return r.WaitOnApplicationContext(context.Background(), name, timeout)
+}
+
+// WaitOnApplicationContext is like WaitOnApplication but uses the given context
+func (r *marathonClient) WaitOnApplicationContext(ctx context.Context, name string, timeout time.Duration) error {
+	waiter := newEventWaiter(EventIDStatusUpdate, matchEventAppID(name))
+	return r.wait(ctx, timeout, waiter, func(ctx context.Context) (bool, error) {
+		return r.appExistAndRunning(ctx, name, waiter), nil
+	})
 }
 
-func (r *marathonClient) appExistAndRunning(name string) bool {
-	app, err := r.Application(name)
+// appExistAndRunning reports whether all the tasks of the application are running, adding its
+// deployments to the waiter, if any
+func (r *marathonClient) appExistAndRunning(ctx context.Context, name string, waiter *eventWaiter) bool {
+	app, err := r.ApplicationContext(ctx, name)
 	if apiErr, ok := err.(*APIError); ok && apiErr.ErrCode == ErrCodeNotFound {
 		return false
 	}
-	if err == nil && app.AllTaskRunning() {
-		return true
+	if err != nil {
+		return false
 	}
-	return false
+	for _, deployment := range app.DeploymentIDs() {
+		waiter.addDeployments(deployment.DeploymentID)
+	}
+	return app.AllTaskRunning()
 }
 
 // DeleteApplication deletes an application from marathon
 // 		name: 		the id used to identify the application
 //		force:		used to force the delete operation in case of blocked deployment
 func (r *marathonClient) DeleteApplication(name string, force bool) (*DeploymentID, error) {
+	return r.DeleteApplicationContext(context.Background(), name, force)
+}
+
+// DeleteApplicationContext is like DeleteApplication but uses the given context
+func (r *marathonClient) DeleteApplicationContext(ctx context.Context, name string, force bool) (*DeploymentID, error) {
 	path := buildPathWithForceParam(name, force)
 	// step: check of the application already exists
 	deployID := new(DeploymentID)
-	if err := r.apiDelete(path, nil, deployID); err != nil {
+	if err := r.apiDelete(ctx, path, nil, deployID); err != nil {
 		return nil, err
 	}
 
@@ -850,10 +924,15 @@ func (r *marathonClient) DeleteApplication(name string, force bool) (*Deployment
 // RestartApplication performs a rolling restart of marathon application
 // 		name: 		the id used to identify the application
 func (r *marathonClient) RestartApplication(name string, force bool) (*DeploymentID, error) {
+	return r.RestartApplicationContext(context.Background(), name, force)
+}
+
+// RestartApplicationContext is like RestartApplication but uses the given context
+func (r *marathonClient) RestartApplicationContext(ctx context.Context, name string, force bool) (*DeploymentID, error) {
 	deployment := new(DeploymentID)
 	var options struct{}
 	path := buildPathWithForceParam(fmt.Sprintf("%s/restart", name), force)
-	if err := r.ApiPost(path, &options, deployment); err != nil {
+	if err := r.apiPost(ctx, path, &options, deployment); err != nil {
 		return nil, err
 	}
 
@@ -865,12 +944,17 @@ func (r *marathonClient) RestartApplication(name string, force bool) (*Deploymen
 // 		instances:	the number of instances you wish to change to
 //    force: used to force the scale operation in case of blocked deployment
 func (r *marathonClient) ScaleApplicationInstances(name string, instances int, force bool) (*DeploymentID, error) {
+	return r.ScaleApplicationInstancesContext(context.Background(), name, instances, force)
+}
+
+// ScaleApplicationInstancesContext is like ScaleApplicationInstances but uses the given context
+func (r *marathonClient) ScaleApplicationInstancesContext(ctx context.Context, name string, instances int, force bool) (*DeploymentID, error) {
 	changes := new(Application)
 	changes.ID = validateID(name)
 	changes.Instances = &instances
 	path := buildPathWithForceParam(name, force)
 	deployID := new(DeploymentID)
-	if err := r.apiPut(path, changes, deployID); err != nil {
+	if err := r.apiPut(ctx, path, changes, deployID); err != nil {
 		return nil, err
 	}
 
@@ -880,9 +964,14 @@ func (r *marathonClient) ScaleApplicationInstances(name string, instances int, f
 // UpdateApplication updates an application in Marathon
 // 		application:		the structure holding the application configuration
 func (r *marathonClient) UpdateApplication(application *Application, force bool) (*DeploymentID, error) {
+	return r.UpdateApplicationContext(context.Background(), application, force)
+}
+
+// UpdateApplicationContext is like UpdateApplication but uses the given context
+func (r *marathonClient) UpdateApplicationContext(ctx context.Context, application *Application, force bool) (*DeploymentID, error) {
 	result := new(DeploymentID)
 	path := buildPathWithForceParam(application.ID, force)
-	if err := r.apiPut(path, application, result); err != nil {
+	if err := r.apiPut(ctx, path, application, result); err != nil {
 		return nil, err
 	}
 	return result, nil
@@ -17,6 +17,7 @@ limitations under the License.
 package marathon
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -768,12 +769,31 @@ func TestWaitOnApplication(t *testing.T) {
 	}
 }
 
+func TestWaitOnApplicationContext(t *testing.T) {
+	endpoint := newFakeMarathonEndpoint(t, nil)
+	defer endpoint.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error)
+	go func() {
+		errCh <- endpoint.Client.WaitOnApplicationContext(ctx, "no_such_app", time.Minute)
+	}()
+	cancel()
+
+	select {
+	case <-time.After(time.Second):
+		assert.Fail(t, "WaitOnApplicationContext did not return after cancellation")
+	case err := <-errCh:
+		assert.Equal(t, context.Canceled, err)
+	}
+}
+
 func TestAppExistAndRunning(t *testing.T) {
 	endpoint := newFakeMarathonEndpoint(t, nil)
 	defer endpoint.Close()
 	client := endpoint.Client.(*marathonClient)
-	assert.True(t, client.appExistAndRunning(fakeAppName))
-	assert.False(t, client.appExistAndRunning("no_such_app"))
+	assert.True(t, client.appExistAndRunning(context.Background(), fakeAppName, nil))
+	assert.False(t, client.appExistAndRunning(context.Background(), "no_such_app", nil))
 }
 
 func TestSetIPPerTask(t *testing.T) {
@@ -0,0 +1,250 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"fmt"
+	"time"
+)
+
+// CircuitBreaker configures the circuit breaker of every member of the cluster. A member which
+// can not be reached has its circuit opened right away. Otherwise, the circuit is opened once
+// the rate of 5xx responses or the rate of slow responses over the latest requests exceeds its
+// threshold. A member whose circuit is open receives no request until its health check passes,
+// which half-opens the circuit: the member is then on trial, and its circuit is closed again
+// after a few successful requests or opened again on the first failure.
+type CircuitBreaker struct {
+	// WindowSize is the number of latest requests the rates are computed over, defaults to 20
+	WindowSize int
+	// MinRequests is the number of requests needed before the rates are considered, defaults to 5
+	MinRequests int
+	// FailureRateThreshold is the rate of 5xx responses opening the circuit, defaults to 0.5
+	FailureRateThreshold float64
+	// SlowCallThreshold is the latency above which a response is slow, zero to ignore latencies
+	SlowCallThreshold time.Duration
+	// SlowCallRateThreshold is the rate of slow responses opening the circuit, defaults to 0.5
+	SlowCallRateThreshold float64
+	// HalfOpenRequests is the number of successful requests closing a half-open circuit, defaults to 3
+	HalfOpenRequests int
+}
+
+// withDefaults returns the configuration with the defaults of the unset fields
+func (b CircuitBreaker) withDefaults() *CircuitBreaker {
+	if b.WindowSize <= 0 {
+		b.WindowSize = 20
+	}
+	if b.MinRequests <= 0 {
+		b.MinRequests = 5
+	}
+	if b.MinRequests > b.WindowSize {
+		b.MinRequests = b.WindowSize
+	}
+	if b.FailureRateThreshold <= 0 {
+		b.FailureRateThreshold = 0.5
+	}
+	if b.SlowCallRateThreshold <= 0 {
+		b.SlowCallRateThreshold = 0.5
+	}
+	if b.HalfOpenRequests <= 0 {
+		b.HalfOpenRequests = 3
+	}
+	return &b
+}
+
+// CircuitState is the state of the circuit breaker of a member
+type CircuitState int
+
+const (
+	// CircuitClosed is the state of a healthy member
+	CircuitClosed CircuitState = iota
+	// CircuitOpen is the state of a member which receives no request
+	CircuitOpen
+	// CircuitHalfOpen is the state of a member on trial after it recovered
+	CircuitHalfOpen
+)
+
+// String returns the name of the state
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// MemberStatus describes the status of a member of the cluster
+type MemberStatus struct {
+	// Endpoint is the URL of the member
+	Endpoint string
+	// State is the state of the circuit breaker of the member
+	State CircuitState
+	// Reason explains the state, empty for a healthy member
+	Reason string
+	// Since is when the member entered the state
+	Since time.Time
+	// Latency is the moving average of the round trip times observed on the member
+	Latency time.Duration
+	// FailureRate is the rate of 5xx responses over the latest requests
+	FailureRate float64
+	// SlowCallRate is the rate of slow responses over the latest requests
+	SlowCallRate float64
+	// Leader is set on the member known to be the current leader
+	Leader bool
+}
+
+// outcome is the outcome of a request sent to a member
+type outcome struct {
+	failed bool
+	slow   bool
+}
+
+// outcomeWindow keeps the outcomes of the latest requests sent to a member
+type outcomeWindow struct {
+	outcomes []outcome
+	next     int
+	full     bool
+}
+
+// add records the outcome, evicting the oldest one when the window is full
+func (w *outcomeWindow) add(o outcome, size int) {
+	if len(w.outcomes) < size && !w.full {
+		w.outcomes = append(w.outcomes, o)
+		if len(w.outcomes) == size {
+			w.full = true
+		}
+		return
+	}
+	w.outcomes[w.next] = o
+	w.next = (w.next + 1) % len(w.outcomes)
+}
+
+// rates returns the number of outcomes and the rates of failed and slow requests
+func (w *outcomeWindow) rates() (int, float64, float64) {
+	if len(w.outcomes) == 0 {
+		return 0, 0, 0
+	}
+	var failed, slow int
+	for _, o := range w.outcomes {
+		if o.failed {
+			failed++
+		}
+		if o.slow {
+			slow++
+		}
+	}
+	count := float64(len(w.outcomes))
+	return len(w.outcomes), float64(failed) / count, float64(slow) / count
+}
+
+// reset forgets about all outcomes
+func (w *outcomeWindow) reset() {
+	*w = outcomeWindow{}
+}
+
+// serverFailure records a 5xx response of the member. Without a circuit breaker, the member is
+// marked as down right away. It returns whether the member is down.
+func (c *cluster) serverFailure(endpoint string, statusCode int, latency time.Duration) bool {
+	if c.breaker == nil {
+		c.markDownWithReason(endpoint, fmt.Sprintf("response with status %d", statusCode))
+		return true
+	}
+	return c.recordOutcome(endpoint, true, latency)
+}
+
+// recordOutcome records the outcome of a request which got a response from the member, opening
+// its circuit when the thresholds are exceeded. It returns whether the circuit was opened.
+func (c *cluster) recordOutcome(endpoint string, failed bool, latency time.Duration) bool {
+	breaker := c.breaker
+	if breaker == nil {
+		return false
+	}
+	slow := breaker.SlowCallThreshold > 0 && latency > breaker.SlowCallThreshold
+
+	c.Lock()
+	defer c.Unlock()
+	for _, n := range c.members {
+		if n.endpoint != endpoint || n.status != memberStatusUp {
+			continue
+		}
+
+		// step: a member on trial is opened again on the first failure
+		if n.halfOpen {
+			switch {
+			case failed:
+				c.markDownLocked(n, "request failed during the half-open trial")
+				return true
+			case slow:
+				c.markDownLocked(n, fmt.Sprintf("slow response during the half-open trial: %s", latency))
+				return true
+			}
+			n.trials++
+			if n.trials >= breaker.HalfOpenRequests {
+				n.halfOpen = false
+				n.reason = ""
+				n.since = time.Now()
+				c.logger.Info("member circuit closed", "member", n.endpoint)
+			}
+			return false
+		}
+
+		n.outcomes.add(outcome{failed: failed, slow: slow}, breaker.WindowSize)
+		count, failureRate, slowRate := n.outcomes.rates()
+		if count < breaker.MinRequests {
+			return false
+		}
+		switch {
+		case failureRate >= breaker.FailureRateThreshold:
+			c.markDownLocked(n, fmt.Sprintf("failure rate of %.0f%% over the last %d requests", failureRate*100, count))
+			return true
+		case slow && slowRate >= breaker.SlowCallRateThreshold:
+			c.markDownLocked(n, fmt.Sprintf("slow call rate of %.0f%% over the last %d requests", slowRate*100, count))
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+// statuses returns the status of every member
+func (c *cluster) statuses() []MemberStatus {
+	c.RLock()
+	defer c.RUnlock()
+	statuses := make([]MemberStatus, 0, len(c.members))
+	for _, n := range c.members {
+		status := MemberStatus{
+			Endpoint: n.endpoint,
+			State:    CircuitClosed,
+			Reason:   n.reason,
+			Since:    n.since,
+			Latency:  n.latency,
+			Leader:   n.endpoint == c.leader,
+		}
+		switch {
+		case n.status == memberStatusDown:
+			status.State = CircuitOpen
+		case n.halfOpen:
+			status.State = CircuitHalfOpen
+		}
+		_, status.FailureRate, status.SlowCallRate = n.outcomes.rates()
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const breakerTestURL = "http://127.0.0.1:3000,127.0.0.2:3000"
+
+func newBreakerCluster(t *testing.T, url string, breaker CircuitBreaker) *cluster {
+	config := Config{HTTPClient: defaultHTTPClient, CircuitBreaker: &breaker}
+	c, err := newCluster(&httpClient{config: config}, url, false)
+	require.NoError(t, err)
+	c.healthCheckInterval = time.Hour
+	return c
+}
+
+func TestOutcomeWindow(t *testing.T) {
+	var window outcomeWindow
+	window.add(outcome{failed: true}, 3)
+	window.add(outcome{slow: true}, 3)
+	count, failureRate, slowRate := window.rates()
+	assert.Equal(t, 2, count)
+	assert.Equal(t, 0.5, failureRate)
+	assert.Equal(t, 0.5, slowRate)
+
+	// step: the oldest outcomes are evicted
+	window.add(outcome{}, 3)
+	window.add(outcome{}, 3)
+	count, failureRate, slowRate = window.rates()
+	assert.Equal(t, 3, count)
+	assert.Equal(t, 0.0, failureRate)
+	assert.InDelta(t, 1.0/3, slowRate, 0.001)
+}
+
+func TestCircuitBreakerFailureRate(t *testing.T) {
+	c := newBreakerCluster(t, breakerTestURL, CircuitBreaker{WindowSize: 4, MinRequests: 4})
+	defer c.close()
+	member := "http://127.0.0.1:3000"
+
+	// step: failures below the minimum number of requests are tolerated
+	assert.False(t, c.recordOutcome(member, true, time.Millisecond))
+	assert.False(t, c.recordOutcome(member, true, time.Millisecond))
+	assert.False(t, c.recordOutcome(member, false, time.Millisecond))
+	assert.Equal(t, CircuitClosed, c.statuses()[0].State)
+	assert.Equal(t, 2.0/3, c.statuses()[0].FailureRate)
+
+	assert.True(t, c.recordOutcome(member, false, time.Millisecond))
+	status := c.statuses()[0]
+	assert.Equal(t, CircuitOpen, status.State)
+	assert.Equal(t, "failure rate of 50% over the last 4 requests", status.Reason)
+	assert.False(t, status.Since.IsZero())
+	assert.Equal(t, []string{"http://127.0.0.2:3000"}, c.activeMembers())
+}
+
+func TestCircuitBreakerSlowCalls(t *testing.T) {
+	c := newBreakerCluster(t, breakerTestURL, CircuitBreaker{
+		WindowSize:            2,
+		SlowCallThreshold:     100 * time.Millisecond,
+		SlowCallRateThreshold: 1,
+	})
+	defer c.close()
+	member := "http://127.0.0.2:3000"
+
+	assert.False(t, c.recordOutcome(member, false, time.Second))
+	assert.False(t, c.recordOutcome(member, false, time.Millisecond))
+	assert.False(t, c.recordOutcome(member, false, time.Second))
+	assert.True(t, c.recordOutcome(member, false, time.Second))
+	assert.Equal(t, "slow call rate of 100% over the last 2 requests", c.statuses()[1].Reason)
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pong"))
+	}))
+	defer server.Close()
+
+	c := newBreakerCluster(t, server.URL, CircuitBreaker{HalfOpenRequests: 2})
+	c.healthCheckInterval = 10 * time.Millisecond
+	defer c.close()
+
+	waitHalfOpen := func() {
+		for deadline := time.Now().Add(time.Second); c.statuses()[0].State != CircuitHalfOpen && time.Now().Before(deadline); {
+			time.Sleep(5 * time.Millisecond)
+		}
+		require.Equal(t, CircuitHalfOpen, c.statuses()[0].State)
+	}
+
+	// step: a member on trial is closed after enough successful requests
+	c.markDownWithReason(server.URL, "connection refused")
+	assert.Equal(t, "connection refused", c.statuses()[0].Reason)
+	waitHalfOpen()
+	assert.False(t, c.recordOutcome(server.URL, false, time.Millisecond))
+	assert.Equal(t, CircuitHalfOpen, c.statuses()[0].State)
+	assert.False(t, c.recordOutcome(server.URL, false, time.Millisecond))
+	assert.Equal(t, CircuitClosed, c.statuses()[0].State)
+	assert.Empty(t, c.statuses()[0].Reason)
+
+	// step: a member on trial is opened again on the first failure
+	c.markDown(server.URL)
+	waitHalfOpen()
+	assert.True(t, c.recordOutcome(server.URL, true, time.Millisecond))
+	assert.Equal(t, CircuitOpen, c.statuses()[0].State)
+	assert.Equal(t, "request failed during the half-open trial", c.statuses()[0].Reason)
+}
+
+func TestCircuitBreakerAPICall(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(w, `{"message": "internal error"}`, http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"apps": []}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{URL: server.URL + ",127.0.0.1:0", CircuitBreaker: &CircuitBreaker{}})
+	require.NoError(t, err)
+	client.(*marathonClient).hosts.healthCheckInterval = time.Hour
+
+	// step: a single failure does not open the circuit of the member
+	_, err = client.Applications(nil)
+	if assert.Error(t, err) {
+		assert.Equal(t, ErrCodeServer, err.(*APIError).ErrCode)
+	}
+	_, err = client.Applications(nil)
+	assert.NoError(t, err)
+
+	members := client.Members()
+	require.Len(t, members, 2)
+	assert.Equal(t, server.URL, members[0].Endpoint)
+	assert.Equal(t, CircuitClosed, members[0].State)
+	assert.Equal(t, 0.5, members[0].FailureRate)
+	assert.True(t, members[0].Latency > 0)
+	assert.Equal(t, CircuitClosed, members[1].State)
+}
+
+func TestCircuitBreakerServerErrorWithoutRetryPolicy(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "internal error"}`, http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	var calls int
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"apps": []}`))
+	}))
+	defer healthy.Close()
+
+	breaker := &CircuitBreaker{WindowSize: 4, MinRequests: 2, FailureRateThreshold: 0.5}
+	client, err := NewClient(Config{URL: failing.URL + "," + healthy.URL, CircuitBreaker: breaker})
+	require.NoError(t, err)
+	client.(*marathonClient).hosts.healthCheckInterval = time.Hour
+
+	// step: the 5xx response is returned while the circuit of the member stays closed
+	_, err = client.Applications(nil)
+	if assert.Error(t, err) {
+		assert.Equal(t, ErrCodeServer, err.(*APIError).ErrCode)
+	}
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, CircuitClosed, client.Members()[0].State)
+
+	// step: the 5xx response opening the circuit is tried on the next member
+	_, err = client.Applications(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, CircuitOpen, client.Members()[0].State)
+}
+
+func TestMembersWithoutCircuitBreaker(t *testing.T) {
+	client, err := NewClient(Config{URL: breakerTestURL})
+	require.NoError(t, err)
+	hosts := client.(*marathonClient).hosts
+	hosts.healthCheckInterval = time.Hour
+	hosts.setLeader("127.0.0.2:3000")
+
+	assert.False(t, hosts.recordOutcome("http://127.0.0.1:3000", true, time.Millisecond))
+	assert.True(t, hosts.serverFailure("http://127.0.0.1:3000", 502, time.Millisecond))
+
+	members := client.Members()
+	require.Len(t, members, 2)
+	assert.Equal(t, CircuitOpen, members[0].State)
+	assert.Equal(t, "response with status 502", members[0].Reason)
+	assert.False(t, members[0].Leader)
+	assert.Equal(t, CircuitClosed, members[1].State)
+	assert.True(t, members[1].Leader)
+	assert.Equal(t, "half-open", CircuitHalfOpen.String())
+}
@@ -18,6 +18,7 @@ package marathon
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -38,154 +39,227 @@ type Marathon interface {
 	// -- GENERIC API ACCESS ---
 
 	ApiPost(path string, post, result interface{}) error
+	ApiPostContext(ctx context.Context, path string, post, result interface{}) error
 
 	// -- APPLICATIONS ---
 
 	// get a listing of the application ids
 	ListApplications(url.Values) ([]string, error)
+	ListApplicationsContext(ctx context.Context, v url.Values) ([]string, error)
 	// a list of application versions
 	ApplicationVersions(name string) (*ApplicationVersions, error)
+	ApplicationVersionsContext(ctx context.Context, name string) (*ApplicationVersions, error)
 	// check a application version exists
 	HasApplicationVersion(name, version string) (bool, error)
+	HasApplicationVersionContext(ctx context.Context, name, version string) (bool, error)
 	// change an application to a different version
 	SetApplicationVersion(name string, version *ApplicationVersion) (*DeploymentID, error)
+	SetApplicationVersionContext(ctx context.Context, name string, version *ApplicationVersion) (*DeploymentID, error)
 	// check if an application is ok
 	ApplicationOK(name string) (bool, error)
+	ApplicationOKContext(ctx context.Context, name string) (bool, error)
 	// create an application in marathon
 	CreateApplication(application *Application) (*Application, error)
+	CreateApplicationContext(ctx context.Context, application *Application) (*Application, error)
 	// delete an application
 	DeleteApplication(name string, force bool) (*DeploymentID, error)
+	DeleteApplicationContext(ctx context.Context, name string, force bool) (*DeploymentID, error)
 	// update an application in marathon
 	UpdateApplication(application *Application, force bool) (*DeploymentID, error)
+	UpdateApplicationContext(ctx context.Context, application *Application, force bool) (*DeploymentID, error)
 	// a list of deployments on a application
 	ApplicationDeployments(name string) ([]*DeploymentID, error)
+	ApplicationDeploymentsContext(ctx context.Context, name string) ([]*DeploymentID, error)
 	// scale a application
 	ScaleApplicationInstances(name string, instances int, force bool) (*DeploymentID, error)
+	ScaleApplicationInstancesContext(ctx context.Context, name string, instances int, force bool) (*DeploymentID, error)
 	// restart an application
 	RestartApplication(name string, force bool) (*DeploymentID, error)
+	RestartApplicationContext(ctx context.Context, name string, force bool) (*DeploymentID, error)
 	// get a list of applications from marathon
 	Applications(url.Values) (*Applications, error)
+	ApplicationsContext(ctx context.Context, v url.Values) (*Applications, error)
 	// get an application by name
 	Application(name string) (*Application, error)
+	ApplicationContext(ctx context.Context, name string) (*Application, error)
 	// get an application by options
 	ApplicationBy(name string, opts *GetAppOpts) (*Application, error)
+	ApplicationByContext(ctx context.Context, name string, opts *GetAppOpts) (*Application, error)
 	// get an application by name and version
 	ApplicationByVersion(name, version string) (*Application, error)
+	ApplicationByVersionContext(ctx context.Context, name, version string) (*Application, error)
 	// wait of application
 	WaitOnApplication(name string, timeout time.Duration) error
+	WaitOnApplicationContext(ctx context.Context, name string, timeout time.Duration) error
 
 	// -- PODS ---
 	// whether this version of Marathon supports pods
 	SupportsPods() (bool, error)
+	SupportsPodsContext(ctx context.Context) (bool, error)
 
 	// get pod status
 	PodStatus(name string) (*PodStatus, error)
+	PodStatusContext(ctx context.Context, name string) (*PodStatus, error)
 	// get all pod statuses
 	PodStatuses() ([]*PodStatus, error)
+	PodStatusesContext(ctx context.Context) ([]*PodStatus, error)
 
 	// get pod
 	Pod(name string) (*Pod, error)
+	PodContext(ctx context.Context, name string) (*Pod, error)
 	// get all pods
 	Pods() ([]Pod, error)
+	PodsContext(ctx context.Context) ([]Pod, error)
 	// create pod
 	CreatePod(pod *Pod) (*Pod, error)
+	CreatePodContext(ctx context.Context, pod *Pod) (*Pod, error)
 	// update pod
 	UpdatePod(pod *Pod, force bool) (*Pod, error)
+	UpdatePodContext(ctx context.Context, pod *Pod, force bool) (*Pod, error)
 	// delete pod
 	DeletePod(name string, force bool) (*DeploymentID, error)
+	DeletePodContext(ctx context.Context, name string, force bool) (*DeploymentID, error)
 	// wait on pod to be deployed
 	WaitOnPod(name string, timeout time.Duration) error
+	WaitOnPodContext(ctx context.Context, name string, timeout time.Duration) error
 	// check if a pod is running
 	PodIsRunning(name string) bool
+	PodIsRunningContext(ctx context.Context, name string) bool
 
 	// get versions of a pod
 	PodVersions(name string) ([]string, error)
+	PodVersionsContext(ctx context.Context, name string) ([]string, error)
 	// get pod by version
 	PodByVersion(name, version string) (*Pod, error)
+	PodByVersionContext(ctx context.Context, name, version string) (*Pod, error)
 
 	// delete instances of a pod
 	DeletePodInstances(name string, instances []string) ([]*PodInstance, error)
+	DeletePodInstancesContext(ctx context.Context, name string, instances []string) ([]*PodInstance, error)
 	// delete pod instance
 	DeletePodInstance(name, instance string) (*PodInstance, error)
+	DeletePodInstanceContext(ctx context.Context, name, instance string) (*PodInstance, error)
 
 	// -- TASKS ---
 
 	// get a list of tasks for a specific application
 	Tasks(application string) (*Tasks, error)
+	TasksContext(ctx context.Context, application string) (*Tasks, error)
 	// get a list of all tasks
 	AllTasks(opts *AllTasksOpts) (*Tasks, error)
+	AllTasksContext(ctx context.Context, opts *AllTasksOpts) (*Tasks, error)
 	// get the endpoints for a service on a application
 	TaskEndpoints(name string, port int, healthCheck bool) ([]string, error)
+	TaskEndpointsContext(ctx context.Context, name string, port int, healthCheck bool) ([]string, error)
 	// kill all the tasks for any application
 	KillApplicationTasks(applicationID string, opts *KillApplicationTasksOpts) (*Tasks, error)
+	KillApplicationTasksContext(ctx context.Context, applicationID string, opts *KillApplicationTasksOpts) (*Tasks, error)
 	// kill a single task
 	KillTask(taskID string, opts *KillTaskOpts) (*Task, error)
+	KillTaskContext(ctx context.Context, taskID string, opts *KillTaskOpts) (*Task, error)
 	// kill the given array of tasks
 	KillTasks(taskIDs []string, opts *KillTaskOpts) error
+	KillTasksContext(ctx context.Context, taskIDs []string, opts *KillTaskOpts) error
 
 	// --- GROUPS ---
 
 	// list all the groups in the system
 	Groups() (*Groups, error)
+	GroupsContext(ctx context.Context) (*Groups, error)
 	// retrieve a specific group from marathon
 	Group(name string) (*Group, error)
+	GroupContext(ctx context.Context, name string) (*Group, error)
 	// list all groups in marathon by options
 	GroupsBy(opts *GetGroupOpts) (*Groups, error)
+	GroupsByContext(ctx context.Context, opts *GetGroupOpts) (*Groups, error)
 	// retrieve a specific group from marathon by options
 	GroupBy(name string, opts *GetGroupOpts) (*Group, error)
+	GroupByContext(ctx context.Context, name string, opts *GetGroupOpts) (*Group, error)
 	// create a group deployment
 	CreateGroup(group *Group) error
+	CreateGroupContext(ctx context.Context, group *Group) error
 	// delete a group
 	DeleteGroup(name string, force bool) (*DeploymentID, error)
+	DeleteGroupContext(ctx context.Context, name string, force bool) (*DeploymentID, error)
 	// update a groups
 	UpdateGroup(id string, group *Group, force bool) (*DeploymentID, error)
+	UpdateGroupContext(ctx context.Context, id string, group *Group, force bool) (*DeploymentID, error)
 	// check if a group exists
 	HasGroup(name string) (bool, error)
+	HasGroupContext(ctx context.Context, name string) (bool, error)
 	// wait for an group to be deployed
 	WaitOnGroup(name string, timeout time.Duration) error
+	WaitOnGroupContext(ctx context.Context, name string, timeout time.Duration) error
 
 	// --- DEPLOYMENTS ---
 
 	// get a list of the deployments
 	Deployments() ([]*Deployment, error)
+	DeploymentsContext(ctx context.Context) ([]*Deployment, error)
 	// delete a deployment
 	DeleteDeployment(id string, force bool) (*DeploymentID, error)
+	DeleteDeploymentContext(ctx context.Context, id string, force bool) (*DeploymentID, error)
 	// check to see if a deployment exists
 	HasDeployment(id string) (bool, error)
+	HasDeploymentContext(ctx context.Context, id string) (bool, error)
 	// wait of a deployment to finish
 	WaitOnDeployment(id string, timeout time.Duration) error
+	WaitOnDeploymentContext(ctx context.Context, id string, timeout time.Duration) error
 
 	// --- SUBSCRIPTIONS ---
 
 	// a list of current subscriptions
 	Subscriptions() (*Subscriptions, error)
+	SubscriptionsContext(ctx context.Context) (*Subscriptions, error)
 	// add a events listener
 	AddEventsListener(filter int) (EventsChannel, error)
+	// add a events listener with the given queue settings
+	AddEventsListenerWithOptions(filter int, options ListenerOptions) (EventsChannel, error)
+	// get the number of events dropped for a listener
+	DroppedEvents(channel EventsChannel) uint64
+	// replay a recording of events to the listeners
+	ReplayEvents(ctx context.Context, recording io.Reader, speed float64) error
 	// remove a events listener
 	RemoveEventsListener(channel EventsChannel)
+	// get the status of the SSE event stream, which is empty for the callback transport
+	EventStreamStatus() EventStreamStatus
+	// get the handler of the callback events, for an external server
+	CallbackHandler() http.Handler
 	// Subscribe a callback URL
 	Subscribe(string) error
+	SubscribeContext(ctx context.Context, callback string) error
 	// Unsubscribe a callback URL
 	Unsubscribe(string) error
+	UnsubscribeContext(ctx context.Context, callback string) error
 
 	// --- QUEUE ---
 	// get marathon launch queue
 	Queue() (*Queue, error)
+	QueueContext(ctx context.Context) (*Queue, error)
 	// resets task launch delay of the specific application
 	DeleteQueueDelay(appID string) error
+	DeleteQueueDelayContext(ctx context.Context, appID string) error
 
 	// --- MISC ---
 
 	// get the marathon url
 	GetMarathonURL() string
+	// get the status of the members of the cluster
+	Members() []MemberStatus
 	// ping the marathon
 	Ping() (bool, error)
+	PingContext(ctx context.Context) (bool, error)
 	// grab the marathon server info
 	Info() (*Info, error)
+	InfoContext(ctx context.Context) (*Info, error)
 	// retrieve the leader info
 	Leader() (string, error)
+	LeaderContext(ctx context.Context) (string, error)
 	// cause the current leader to abdicate
 	AbdicateLeader() (string, error)
+	AbdicateLeaderContext(ctx context.Context) (string, error)
+	// stop all background activity of the client
+	Close(ctx context.Context) error
 }
 
 var (
@@ -193,6 +267,10 @@ var (
 	ErrMarathonDown = errors.New("all the Marathon hosts are presently down")
 	// ErrTimeoutError is thrown when the operation has timed out
 	ErrTimeoutError = errors.New("the operation has timed out")
+	// ErrClientClosed is thrown when listening to events on a closed client
+	ErrClientClosed = errors.New("the client has been closed")
+	// ErrDispatcherRunning is thrown when running or registering handlers to a running event dispatcher
+	ErrDispatcherRunning = errors.New("the event dispatcher is already running")
 
 	// Default HTTP client used for SSE subscription requests
 	// It is invalid to set client.Timeout because it includes time to read response so
@@ -215,9 +293,18 @@ var (
 
 // EventsChannelContext holds contextual data for an EventsChannel.
 type EventsChannelContext struct {
-	filter     int
-	done       chan struct{}
-	completion *sync.WaitGroup
+	filter int
+	done   chan struct{}
+	// the events pending delivery, in order
+	queue chan *Event
+	// the policy applied once the queue is full
+	overflow OverflowPolicy
+	// the predicates the events must match
+	predicates []EventPredicate
+	// the number of events dropped
+	dropped *uint64
+	// the identifier of the listener in the metrics
+	id int
 }
 
 type marathonClient struct {
@@ -226,18 +313,38 @@ type marathonClient struct {
 	config Config
 	// the flag used to prevent multiple SSE subscriptions
 	subscribedToSSE bool
-	// the ip address of the client
-	ipAddress string
+	// the address of the callback server advertised to Marathon
+	callbackAddress string
 	// the http server
 	eventsHTTP *http.Server
 	// the marathon hosts
 	hosts *cluster
 	// a map of service you wish to listen to
 	listeners map[EventsChannel]EventsChannelContext
-	// a custom log function for debug messages
-	debugLog func(format string, v ...interface{})
+	// the identifier of the last events listener added
+	lastListenerID int
+	// the waits woken up by the events
+	waiters map[*eventWaiter]bool
+	// the status of the SSE event stream
+	streamStatus EventStreamStatus
+	// signals the SSE event stream that the listeners changed
+	streamFilterChanged chan struct{}
+	// the logger of the client
+	logger Logger
+	// serializes the writes to the events recorder
+	recorderLock sync.Mutex
+	// the limits of the API requests reading and changing state
+	readLimiter  *requestLimiter
+	writeLimiter *requestLimiter
 	// the marathon HTTP client to ensure consistency in requests
 	client *httpClient
+	// the context of the background goroutines, cancelled on Close
+	ctx    context.Context
+	cancel context.CancelFunc
+	// the background goroutines to wait for on Close
+	background sync.WaitGroup
+	// the flag used to prevent using the client after Close
+	closed bool
 }
 
 type httpClient struct {
@@ -267,74 +374,206 @@ func NewClient(config Config) (Marathon, error) {
 		config.HTTPClient = defaultHTTPClient
 	}
 
+	// step: apply the TLS configuration to both HTTP clients
+	if config.TLS != nil {
+		tlsConfig, err := config.TLS.build()
+		if err != nil {
+			return nil, err
+		}
+		sharedClient := config.HTTPSSEClient == config.HTTPClient
+		if config.HTTPClient, err = withTLSConfig(config.HTTPClient, tlsConfig); err != nil {
+			return nil, err
+		}
+		if sharedClient {
+			config.HTTPSSEClient = config.HTTPClient
+		} else if config.HTTPSSEClient, err = withTLSConfig(config.HTTPSSEClient, tlsConfig); err != nil {
+			return nil, err
+		}
+	}
+
 	// step: if no polling wait time is set, default to 500 milliseconds.
 	if config.PollingWaitTime == 0 {
 		config.PollingWaitTime = defaultPollingWaitTime
 	}
+	if config.EventsPollingWaitTime == 0 {
+		config.EventsPollingWaitTime = eventsPollingWaitTimeFactor * config.PollingWaitTime
+	}
+
+	// step: if no member discovery interval is set, default to 30 seconds.
+	if config.MemberSource != nil && config.MemberSourceInterval == 0 {
+		config.MemberSourceInterval = defaultMemberSourceInterval
+	}
+
+	// step: discover the initial members when no URL is given
+	if config.URL == "" && config.MemberSource != nil {
+		endpoints, err := config.MemberSource(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		config.URL = strings.Join(endpoints, ",")
+	}
 
 	// step: setup shared client
 	client := &httpClient{config: config}
 
 	// step: create a new cluster
-	hosts, err := newCluster(client, config.URL, config.DCOSToken != "")
+	hosts, err := newCluster(client, config.URL, config.DCOSToken != "" || config.DCOSTokenProvider != nil)
 	if err != nil {
 		return nil, err
 	}
 
-	debugLog := func(string, ...interface{}) {}
-	if config.LogOutput != nil {
-		logger := log.New(config.LogOutput, "", 0)
-		debugLog = func(format string, v ...interface{}) {
-			logger.Printf(format, v...)
+	// step: fall back to logging debug messages to the log output
+	logger := config.Logger
+	if logger == nil {
+		logger = NewNopLogger()
+		if config.LogOutput != nil && config.LogOutput != ioutil.Discard {
+			logger = NewStdLogger(log.New(config.LogOutput, "", 0), LogLevelDebug)
 		}
 	}
+	hosts.logger = logger
+
+	if config.MemberSource != nil {
+		hosts.discoverMembers(config.MemberSource, config.MemberSourceInterval)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
 
 	return &marathonClient{
-		config:    config,
-		listeners: make(map[EventsChannel]EventsChannelContext),
-		hosts:     hosts,
-		debugLog:  debugLog,
-		client:    client,
+		config:              config,
+		listeners:           make(map[EventsChannel]EventsChannelContext),
+		waiters:             make(map[*eventWaiter]bool),
+		streamFilterChanged: make(chan struct{}, 1),
+		hosts:               hosts,
+		logger:              logger,
+		readLimiter:         newRequestLimiter("read", config.ReadLimits, client.metrics),
+		writeLimiter:        newRequestLimiter("write", config.WriteLimits, client.metrics),
+		client:              client,
+		ctx:                 ctx,
+		cancel:              cancel,
 	}, nil
 }
 
+// Close stops all background activity of the client: it removes the callback subscription, shuts
+// down the events HTTP server, stops the SSE stream and the health checks of down members, and
+// closes all the events listener channels. It waits for all of this to be done or for the context
+// to be done, whichever happens first.
+func (r *marathonClient) Close(ctx context.Context) error {
+	r.Lock()
+	if r.closed {
+		r.Unlock()
+		return nil
+	}
+	r.closed = true
+	subscribed := r.config.EventsTransport == EventsTransportCallback && len(r.listeners) > 0 &&
+		(r.eventsHTTP != nil || r.config.EventsExternalServer)
+	for channel := range r.listeners {
+		r.removeEventsListener(channel)
+	}
+	r.Unlock()
+
+	var firstErr error
+	// step: remove ourselves from the events callback
+	if subscribed {
+		if err := r.UnsubscribeContext(ctx, r.SubscriptionURL()); err != nil {
+			r.logger.Warn("failed to unsubscribe on close", "error", err)
+			firstErr = err
+		}
+	}
+
+	// step: shutdown the events HTTP server, waiting for pending callbacks
+	if r.eventsHTTP != nil {
+		if err := r.eventsHTTP.Shutdown(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	// step: stop the SSE stream and the health checks
+	r.cancel()
+	r.hosts.close()
+
+	done := make(chan struct{})
+	go func() {
+		r.background.Wait()
+		r.hosts.wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return firstErr
+}
+
 // GetMarathonURL retrieves the marathon url
 func (r *marathonClient) GetMarathonURL() string {
 	return r.config.URL
 }
 
+// Members returns the status of the members of the cluster, in the order of the URL
+func (r *marathonClient) Members() []MemberStatus {
+	return r.hosts.statuses()
+}
+
 // Ping pings the current marathon endpoint (note, this is not a ICMP ping, but a rest api call)
 func (r *marathonClient) Ping() (bool, error) {
-	if err := r.apiGet(marathonAPIPing, nil, nil); err != nil {
+	return r.PingContext(context.Background())
+}
+
+// PingContext is like Ping but uses the given context
+func (r *marathonClient) PingContext(ctx context.Context) (bool, error) {
+	if err := r.apiGet(ctx, marathonAPIPing, nil, nil); err != nil {
 		return false, err
 	}
 	return true, nil
 }
 
-func (r *marathonClient) apiHead(path string, result interface{}) error {
-	return r.apiCall("HEAD", path, nil, result)
+func (r *marathonClient) apiHead(ctx context.Context, path string, result interface{}) error {
+	return r.apiCall(ctx, "HEAD", path, nil, result)
+}
+
+func (r *marathonClient) apiGet(ctx context.Context, path string, post, result interface{}) error {
+	return r.apiCall(ctx, "GET", path, post, result)
+}
+
+func (r *marathonClient) apiPut(ctx context.Context, path string, post, result interface{}) error {
+	return r.apiCall(ctx, "PUT", path, post, result)
 }
 
-func (r *marathonClient) apiGet(path string, post, result interface{}) error {
-	return r.apiCall("GET", path, post, result)
+func (r *marathonClient) apiPost(ctx context.Context, path string, post, result interface{}) error {
+	return r.apiCall(ctx, "POST", path, post, result)
 }
 
-func (r *marathonClient) apiPut(path string, post, result interface{}) error {
-	return r.apiCall("PUT", path, post, result)
+func (r *marathonClient) apiDelete(ctx context.Context, path string, post, result interface{}) error {
+	return r.apiCall(ctx, "DELETE", path, post, result)
 }
 
 func (r *marathonClient) ApiPost(path string, post, result interface{}) error {
-	return r.apiCall("POST", path, post, result)
+	return r.ApiPostContext(context.Background(), path, post, result)
 }
 
-func (r *marathonClient) apiDelete(path string, post, result interface{}) error {
-	return r.apiCall("DELETE", path, post, result)
+// ApiPostContext is like ApiPost but uses the given context
+func (r *marathonClient) ApiPostContext(ctx context.Context, path string, post, result interface{}) error {
+	return r.apiPost(ctx, path, post, result)
 }
 
-func (r *marathonClient) apiCall(method, path string, body, result interface{}) error {
+func (r *marathonClient) apiCall(ctx context.Context, method, path string, body, result interface{}) error {
 	const deploymentHeader = "Marathon-Deployment-Id"
 
-	for {
+	// step: writes go straight to the leader, so look it up if it is not known
+	if !isReadMethod(method) && r.hosts.leaderStale() {
+		if _, err := r.LeaderContext(ctx); err != nil {
+			r.logger.Warn("failed to look up the leader", "error", err)
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		// step: stop trying other members once the caller has given up
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		// step: marshall the request to json
 		var requestBody []byte
 		var err error
@@ -344,32 +583,71 @@ func (r *marathonClient) apiCall(method, path string, body, result interface{})
 			}
 		}
 
+		// step: wait for the request limits
+		limiter := r.writeLimiter
+		if isReadMethod(method) {
+			limiter = r.readLimiter
+		}
+		release, err := limiter.acquire(ctx)
+		if err != nil {
+			return err
+		}
+
 		// step: create the API request
-		request, member, err := r.buildAPIRequest(method, path, bytes.NewReader(requestBody))
+		request, member, err := r.buildAPIRequest(ctx, method, path, bytes.NewReader(requestBody))
 		if err != nil {
+			release()
 			return err
 		}
 
 		// step: perform the API request
-		response, err := r.client.Do(request)
+		call := &Call{Request: request, Path: path, Member: member, Body: requestBody}
+		response, err := r.client.Do(call)
+		if err == nil {
+			r.hosts.observeLatency(member, call.Latency)
+		}
 		if err != nil {
-			r.hosts.markDown(member)
+			release()
+			// step: a cancelled or expired context is not the member's fault, nor is a failed login
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if isDCOSLoginError(err) {
+				return err
+			}
+			r.hosts.markDownWithReason(member, err.Error())
 			// step: attempt the request on another member
-			r.debugLog("apiCall(): request failed on host: %s, error: %s, trying another", member, err)
+			r.logger.Warn("request failed, trying another member", "method", method, "path", path, "member", member, "error", err)
+			failure := &RequestFailure{Method: method, Path: path, Member: member, Err: err}
+			if retryErr := r.retryAfterFailure(ctx, attempt, failure); retryErr != nil {
+				if retryErr == errNoRetry {
+					return err
+				}
+				return retryErr
+			}
 			continue
 		}
 		defer response.Body.Close()
 
+		// step: keep track of the leader reported by the member
+		if leader := response.Header.Get(leaderHeader); leader != "" {
+			r.hosts.setLeader(leader)
+		}
+
 		// step: read the response body
 		respBody, err := ioutil.ReadAll(response.Body)
+		release()
 		if err != nil {
 			return err
 		}
 
-		if len(requestBody) > 0 {
-			r.debugLog("apiCall(): %v %v %s returned %v %s", request.Method, request.URL.String(), requestBody, response.Status, oneLogLine(respBody))
-		} else {
-			r.debugLog("apiCall(): %v %v returned %v %s", request.Method, request.URL.String(), response.Status, oneLogLine(respBody))
+		r.logger.Debug("request done", "method", method, "path", path, "member", member,
+			"status", response.StatusCode, "request", string(requestBody), "response", string(oneLogLine(respBody)))
+
+		// step: feed the circuit breaker of the member, which 5xx responses are failures of
+		serverError := response.StatusCode >= 500 && response.StatusCode <= 599
+		if !serverError {
+			r.hosts.recordOutcome(member, false, call.Latency)
 		}
 
 		// step: check for a successful response
@@ -395,44 +673,85 @@ func (r *marathonClient) apiCall(method, path string, body, result interface{})
 			return nil
 		}
 
-		// step: if the member node returns a >= 500 && <= 599 we should try another node?
-		if response.StatusCode >= 500 && response.StatusCode <= 599 {
-			// step: mark the host as down
-			r.hosts.markDown(member)
-			r.debugLog("apiCall(): request failed, host: %s, status: %d, trying another", member, response.StatusCode)
-			continue
+		// step: without a retry policy, a >= 500 && <= 599 is retried on another node
+		if r.config.RetryPolicy == nil {
+			if serverError && r.hosts.serverFailure(member, response.StatusCode, call.Latency) {
+				r.logger.Warn("request failed, trying another member", "method", method, "path", path, "member", member, "status", response.StatusCode)
+				continue
+			}
+
+			return NewAPIError(response.StatusCode, respBody)
 		}
 
-		return NewAPIError(response.StatusCode, respBody)
+		// step: with a retry policy, let it decide on server errors and throttling. A member
+		// which has no leader elected yet is fine otherwise, so is not marked as down.
+		failure := &RequestFailure{
+			Method:           method,
+			Path:             path,
+			Member:           member,
+			StatusCode:       response.StatusCode,
+			RetryAfter:       parseRetryAfter(response.Header.Get("Retry-After")),
+			LeaderNotElected: isLeaderNotElected(response.StatusCode, respBody),
+		}
+		switch {
+		case failure.LeaderNotElected:
+			r.logger.Warn("no leader elected", "method", method, "path", path, "member", member, "status", response.StatusCode)
+		case serverError:
+			r.hosts.serverFailure(member, response.StatusCode, call.Latency)
+			r.logger.Warn("request failed, trying another member", "method", method, "path", path, "member", member, "status", response.StatusCode)
+		case response.StatusCode == http.StatusTooManyRequests:
+		default:
+			return NewAPIError(response.StatusCode, respBody)
+		}
+		if retryErr := r.retryAfterFailure(ctx, attempt, failure); retryErr != nil {
+			if retryErr == errNoRetry {
+				return NewAPIError(response.StatusCode, respBody)
+			}
+			return retryErr
+		}
 	}
 }
 
-// wait waits until the provided function returns true (or times out)
-func (r *marathonClient) wait(name string, timeout time.Duration, fn func(string) bool) error {
-	timer := time.NewTimer(timeout)
-	defer timer.Stop()
+// errNoRetry signals that a failed request must not be attempted again
+var errNoRetry = errors.New("no retry")
 
-	ticker := time.NewTicker(r.config.PollingWaitTime)
-	defer ticker.Stop()
-	for {
-		if fn(name) {
-			return nil
-		}
+// retryAfterFailure consults the retry policy about a failed attempt and waits for the delay
+// it asks for. It returns errNoRetry when the request should not be retried, and the context
+// error when the context is done while waiting. Without a retry policy, requests are always
+// retried right away.
+func (r *marathonClient) retryAfterFailure(ctx context.Context, attempt int, failure *RequestFailure) error {
+	if r.config.RetryPolicy == nil {
+		return nil
+	}
 
-		select {
-		case <-timer.C:
-			return ErrTimeoutError
-		case <-ticker.C:
-			continue
-		}
+	delay, retry := r.config.RetryPolicy.RetryDelay(attempt, failure)
+	if !retry {
+		return errNoRetry
+	}
+	if delay <= 0 {
+		return nil
+	}
+
+	r.logger.Info("retrying request", "method", failure.Method, "path", failure.Path, "delay", delay, "attempt", attempt)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
 }
 
 // buildAPIRequest creates a default API request.
 // It fails when there is no available member in the cluster anymore or when the request can not be built.
-func (r *marathonClient) buildAPIRequest(method, path string, reader io.Reader) (request *http.Request, member string, err error) {
-	// Grab a member from the cluster
-	member, err = r.hosts.getMember()
+func (r *marathonClient) buildAPIRequest(ctx context.Context, method, path string, reader io.Reader) (request *http.Request, member string, err error) {
+	// Grab a member from the cluster, writes are sent to the leader
+	if isReadMethod(method) {
+		member, err = r.hosts.getMember()
+	} else {
+		member, err = r.hosts.getLeader()
+	}
 	if err != nil {
 		return nil, "", ErrMarathonDown
 	}
@@ -442,7 +761,7 @@ func (r *marathonClient) buildAPIRequest(method, path string, reader io.Reader)
 	if err != nil {
 		return nil, member, newRequestError{err}
 	}
-	return request, member, nil
+	return request.WithContext(ctx), member, nil
 }
 
 // buildMarathonJSONRequest is like buildMarathonRequest but sets the
@@ -485,8 +804,25 @@ func (rc *httpClient) buildMarathonRequest(method, member, path string, reader i
 	return request, nil
 }
 
-func (rc *httpClient) Do(request *http.Request) (response *http.Response, err error) {
-	return rc.config.HTTPClient.Do(request)
+// metrics returns the configured metrics, discarding them when there are none
+func (rc *httpClient) metrics() Metrics {
+	if rc.config.Metrics == nil {
+		return noopMetrics{}
+	}
+	return rc.config.Metrics
+}
+
+// Do sends the call through the configured middlewares and the HTTP client
+func (rc *httpClient) Do(call *Call) (response *http.Response, err error) {
+	if call.Method == "" {
+		call.Method = call.Request.Method
+	}
+	return rc.handleCall(call, rc.config.HTTPClient.Do)
+}
+
+// isReadMethod checks whether requests of the given method only read state
+func isReadMethod(method string) bool {
+	return method == "GET" || method == "HEAD"
 }
 
 var oneLogLineRegex = regexp.MustCompile(`(?m)^\s*`)
@@ -18,9 +18,14 @@ package marathon
 
 import (
 	"bytes"
-	"testing"
-
+	"context"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -107,9 +112,9 @@ func TestLogOutput(t *testing.T) {
 	cl, err := NewClient(config)
 	require.Nil(t, err)
 
-	cl.(*marathonClient).debugLog("this is a %s", "test")
+	cl.(*marathonClient).logger.Debug("this is a test", "member", "http://marathon")
 
-	assert.Equal(t, "this is a test\n", buf.String())
+	assert.Equal(t, "level=debug msg=\"this is a test\" member=http://marathon\n", buf.String())
 }
 
 func TestInvalidConfig(t *testing.T) {
@@ -129,6 +134,81 @@ func TestPing(t *testing.T) {
 	assert.True(t, pong)
 }
 
+func TestAPIRequestContext(t *testing.T) {
+	endpoint := newFakeMarathonEndpoint(t, nil)
+	defer endpoint.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := endpoint.Client.ApplicationsContext(ctx, nil)
+	assert.Equal(t, context.Canceled, err)
+
+	client := endpoint.Client.(*marathonClient)
+	assert.Empty(t, client.hosts.nonActiveMembers())
+}
+
+func TestAPIRequestContextDeadline(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	client, err := NewClient(Config{URL: server.URL})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = client.PingContext(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Empty(t, client.(*marathonClient).hosts.nonActiveMembers())
+}
+
+func TestAPIRequestLeaderRouting(t *testing.T) {
+	var leaderHits, followerHits int32
+	var leaderURL string
+	leader := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&leaderHits, 1)
+		w.Header().Set(leaderHeader, leaderURL)
+		w.Write([]byte(`{}`))
+	}))
+	defer leader.Close()
+	leaderURL = leader.URL
+	follower := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&followerHits, 1)
+		if r.URL.Path == "/"+marathonAPILeader {
+			w.Write([]byte(fmt.Sprintf(`{"leader": "%s"}`, strings.TrimPrefix(leaderURL, "http://"))))
+			return
+		}
+		w.Header().Set(leaderHeader, leaderURL)
+		w.Write([]byte(`{}`))
+	}))
+	defer follower.Close()
+
+	client, err := NewClient(Config{URL: follower.URL + "," + leader.URL})
+	require.NoError(t, err)
+
+	// step: the first write looks up the leader and is sent to it
+	_, err = client.UpdateApplication(&Application{ID: "/app"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&followerHits))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&leaderHits))
+
+	// step: reads still go to the first member
+	_, err = client.Info()
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&followerHits))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&leaderHits))
+
+	_, err = client.DeleteApplication("/app", false)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&followerHits))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&leaderHits))
+}
+
 func TestGetMarathonURL(t *testing.T) {
 	endpoint := newFakeMarathonEndpoint(t, nil)
 	defer endpoint.Close()
@@ -245,7 +325,7 @@ func TestBuildApiRequestFailure(t *testing.T) {
 			}
 		}
 
-		_, _, err := client.buildAPIRequest("GET", test.path, nil)
+		_, _, err := client.buildAPIRequest(context.Background(), "GET", test.path, nil)
 
 		if test.expectedError != nil {
 			assert.Equal(t, test.expectedError, err)
@@ -17,6 +17,7 @@ limitations under the License.
 package marathon
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strings"
@@ -42,6 +43,26 @@ type cluster struct {
 	// healthCheckInterval is the interval by which we probe down nodes for
 	// availability again.
 	healthCheckInterval time.Duration
+	// the endpoint of the member known to be the current leader, if any
+	leader string
+	// the last time the leader was looked up or learned
+	leaderUpdated time.Time
+	// selector picks the member requests are sent to
+	selector MemberSelector
+	// the context of the health checks and the discovery, cancelled on close
+	ctx    context.Context
+	cancel context.CancelFunc
+	// the running health checks and discovery
+	background sync.WaitGroup
+	// the flag used to prevent health checks after close
+	closed bool
+	// whether the members are DCOS endpoints
+	isDCOS bool
+	// the logger of the client
+	logger Logger
+	// the circuit breaker configuration of the members, nil when members are marked as down
+	// on the first failure
+	breaker *CircuitBreaker
 }
 
 // member represents an individual endpoint
@@ -50,15 +71,60 @@ type member struct {
 	endpoint string
 	// the status of the host
 	status memberStatus
+	// the moving average of the observed round trip times
+	latency time.Duration
+	// whether the member has been removed from the cluster
+	removed bool
+	// why the member is down or on trial
+	reason string
+	// when the member entered its current state
+	since time.Time
+	// whether the member is on trial after recovering
+	halfOpen bool
+	// the number of successful requests of the trial
+	trials int
+	// the outcomes of the latest requests, when a circuit breaker is configured
+	outcomes outcomeWindow
 }
 
 // newCluster returns a new marathon cluster
 func newCluster(client *httpClient, marathonURL string, isDCOS bool) (*cluster, error) {
-	// step: extract and basic validate the endpoints
+	members, err := parseMembers(strings.Split(marathonURL, ","), isDCOS)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := client.config.MemberSelector
+	if selector == nil {
+		selector = NewFirstMemberSelector()
+	}
+
+	var breaker *CircuitBreaker
+	if client.config.CircuitBreaker != nil {
+		breaker = client.config.CircuitBreaker.withDefaults()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &cluster{
+		client:              client,
+		members:             members,
+		healthCheckInterval: 5 * time.Second,
+		selector:            selector,
+		ctx:                 ctx,
+		cancel:              cancel,
+		isDCOS:              isDCOS,
+		logger:              NewNopLogger(),
+		breaker:             breaker,
+	}, nil
+}
+
+// parseMembers extracts and validates the members from a list of endpoints
+func parseMembers(endpoints []string, isDCOS bool) ([]*member, error) {
 	var members []*member
 	var defaultProto string
 
-	for _, endpoint := range strings.Split(marathonURL, ",") {
+	for _, endpoint := range endpoints {
 		// step: check for nothing
 		if endpoint == "" {
 			return nil, newInvalidEndpointError("endpoint is blank")
@@ -96,55 +162,272 @@ func newCluster(client *httpClient, marathonURL string, isDCOS bool) (*cluster,
 		members = append(members, &member{endpoint: u.String()})
 	}
 
-	return &cluster{
-		client:              client,
-		members:             members,
-		healthCheckInterval: 5 * time.Second,
-	}, nil
+	return members, nil
+}
+
+// updateMembers replaces the members of the cluster with the given endpoints. Members which
+// remain part of the cluster keep their status.
+func (c *cluster) updateMembers(endpoints []string) error {
+	members, err := parseMembers(endpoints, c.isDCOS)
+	if err != nil {
+		return err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	current := make(map[string]*member, len(c.members))
+	for _, n := range c.members {
+		current[n.endpoint] = n
+	}
+	for i, n := range members {
+		if existing, found := current[n.endpoint]; found {
+			members[i] = existing
+			delete(current, n.endpoint)
+		}
+	}
+	// step: whatever is left has been removed, which stops its health check
+	for _, n := range current {
+		n.removed = true
+		if n.endpoint == c.leader {
+			c.leader = ""
+			c.leaderUpdated = time.Time{}
+		}
+	}
+	c.members = members
+
+	return nil
+}
+
+// discoverMembers starts refreshing the members of the cluster from the source at the given
+// interval, until the cluster is closed
+func (c *cluster) discoverMembers(source MemberSource, interval time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+	if c.closed {
+		return
+	}
+
+	c.background.Add(1)
+	go func() {
+		defer c.background.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			endpoints, err := source(c.ctx)
+			if err != nil {
+				c.logger.Warn("failed to discover the members", "error", err)
+				continue
+			}
+			if len(endpoints) == 0 {
+				c.logger.Warn("no members discovered, keeping the current ones")
+				continue
+			}
+			if err := c.updateMembers(endpoints); err != nil {
+				c.logger.Error("invalid members discovered", "members", strings.Join(endpoints, ","), "error", err)
+			}
+		}
+	}()
 }
 
 // retrieve the current member, i.e. the current endpoint in use
 func (c *cluster) getMember() (string, error) {
 	c.RLock()
-	defer c.RUnlock()
+	var candidates []Member
 	for _, n := range c.members {
 		if n.status == memberStatusUp {
+			candidates = append(candidates, Member{Endpoint: n.endpoint, Latency: n.latency})
+		}
+	}
+	c.RUnlock()
+
+	if len(candidates) == 0 {
+		return "", ErrMarathonDown
+	}
+	index := c.selector.Select(candidates)
+	if index < 0 || index >= len(candidates) {
+		index = 0
+	}
+
+	return candidates[index].Endpoint, nil
+}
+
+// observeLatency records the round trip time of a request sent to the member
+func (c *cluster) observeLatency(endpoint string, latency time.Duration) {
+	// the weight of the latest observation in the moving average
+	const weight = 0.3
+
+	c.Lock()
+	defer c.Unlock()
+	for _, n := range c.members {
+		if n.endpoint == endpoint {
+			if n.latency == 0 {
+				n.latency = latency
+			} else {
+				n.latency = time.Duration(weight*float64(latency) + (1-weight)*float64(n.latency))
+			}
+			return
+		}
+	}
+}
+
+// getLeader returns the member known to be the current leader, falling back to
+// the current member when the leader is unknown or down
+func (c *cluster) getLeader() (string, error) {
+	c.RLock()
+	for _, n := range c.members {
+		if n.status == memberStatusUp && n.endpoint == c.leader {
+			c.RUnlock()
 			return n.endpoint, nil
 		}
 	}
+	c.RUnlock()
+
+	return c.getMember()
+}
+
+// setLeader records the leader reported by Marathon, either through the
+// /v2/leader API or a leader response header. The address is a host:port pair
+// or a URL, and is matched against the host of the members; an address which
+// matches no member clears the leader.
+func (c *cluster) setLeader(address string) {
+	host := address
+	if u, err := url.Parse(address); err == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	c.leader = ""
+	c.leaderUpdated = time.Now()
+	for _, n := range c.members {
+		if u, err := url.Parse(n.endpoint); err == nil && u.Host == host {
+			c.leader = n.endpoint
+			return
+		}
+	}
+}
 
-	return "", ErrMarathonDown
+// leaderStale checks whether the leader is unknown and has not been looked up
+// for longer than the health check interval
+func (c *cluster) leaderStale() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.leader == "" && time.Since(c.leaderUpdated) > c.healthCheckInterval
+}
+
+// resetLeader forgets about the current leader, e.g. after it abdicated
+func (c *cluster) resetLeader() {
+	c.Lock()
+	defer c.Unlock()
+	c.leader = ""
+	c.leaderUpdated = time.Time{}
 }
 
 // markDown marks down the current endpoint
 func (c *cluster) markDown(endpoint string) {
+	c.markDownWithReason(endpoint, "marked down")
+}
+
+// markDownWithReason marks down the endpoint, opening its circuit, for the given reason
+func (c *cluster) markDownWithReason(endpoint, reason string) {
 	c.Lock()
 	defer c.Unlock()
+	// step: a leader which is down has to be looked up again
+	if c.leader == endpoint {
+		c.leader = ""
+		c.leaderUpdated = time.Time{}
+	}
 	for _, n := range c.members {
 		// step: check if this is the node and it's marked as up - The double  checking on the
 		// nodes status ensures the multiple calls don't create multiple checks
 		if n.status == memberStatusUp && n.endpoint == endpoint {
-			n.status = memberStatusDown
-			go c.healthCheckNode(n)
+			c.markDownLocked(n, reason)
 			break
 		}
 	}
 }
 
+// markDownLocked marks down a member which is up and starts its health check. The caller must
+// hold the lock.
+func (c *cluster) markDownLocked(n *member, reason string) {
+	// step: a leader which is down has to be looked up again
+	if c.leader == n.endpoint {
+		c.leader = ""
+		c.leaderUpdated = time.Time{}
+	}
+	n.status = memberStatusDown
+	n.halfOpen = false
+	n.reason = reason
+	n.since = time.Now()
+	n.outcomes.reset()
+	c.client.metrics().MemberDown(n.endpoint)
+	c.logger.Warn("member marked as down", "member", n.endpoint, "reason", reason)
+	if !c.closed {
+		c.background.Add(1)
+		go c.healthCheckNode(n)
+	}
+}
+
+// close stops the health checks of the down members and the discovery
+func (c *cluster) close() {
+	c.Lock()
+	defer c.Unlock()
+	if !c.closed {
+		c.closed = true
+		c.cancel()
+	}
+}
+
+// wait waits for the health checks and the discovery to stop after close
+func (c *cluster) wait() {
+	c.background.Wait()
+}
+
 // healthCheckNode performs a health check on the node and when active updates the status
 func (c *cluster) healthCheckNode(node *member) {
+	defer c.background.Done()
 	// step: wait for the node to become active ... we are assuming a /ping is enough here
 	ticker := time.NewTicker(c.healthCheckInterval)
 	defer ticker.Stop()
-	for range ticker.C {
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		c.RLock()
+		removed := node.removed
+		c.RUnlock()
+		if removed {
+			return
+		}
 		req, err := c.client.buildMarathonRequest("GET", node.endpoint, "ping", nil)
 		if err == nil {
-			res, err := c.client.Do(req)
+			call := &Call{Request: req.WithContext(c.ctx), Path: "ping", Member: node.endpoint}
+			res, err := c.client.Do(call)
+			if err == nil {
+				res.Body.Close()
+				c.observeLatency(node.endpoint, call.Latency)
+			}
 			if err == nil && res.StatusCode == 200 {
-				// step: mark the node as active again
+				// step: mark the node as active again, on trial with a circuit breaker
 				c.Lock()
 				node.status = memberStatusUp
+				node.since = time.Now()
+				node.reason = ""
+				if c.breaker != nil {
+					node.halfOpen = true
+					node.trials = 0
+					node.reason = "health check passed, on trial"
+				}
 				c.Unlock()
+				c.logger.Info("member is up again", "member", node.endpoint)
 				break
 			}
 		}
@@ -177,6 +460,8 @@ func (c *cluster) membersList(status memberStatus) []string {
 
 // size returns the size of the cluster
 func (c *cluster) size() int {
+	c.RLock()
+	defer c.RUnlock()
 	return len(c.members)
 }
 
@@ -110,6 +110,69 @@ func TestMarkDown(t *testing.T) {
 	}
 }
 
+func TestClusterLeader(t *testing.T) {
+	cluster, err := newStandardCluster("http://127.0.0.1:8080,127.0.0.2:8080,127.0.0.3:8080")
+	require.NoError(t, err)
+
+	// step: an unknown leader falls back to the current member
+	leader, err := cluster.getLeader()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:8080", leader)
+	cluster.healthCheckInterval = time.Hour
+	assert.True(t, cluster.leaderStale())
+
+	cluster.setLeader("127.0.0.2:8080")
+	leader, err = cluster.getLeader()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.2:8080", leader)
+	assert.False(t, cluster.leaderStale())
+
+	cluster.setLeader("http://127.0.0.3:8080")
+	leader, err = cluster.getLeader()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.3:8080", leader)
+
+	// step: a leader which is no member is not known
+	cluster.setLeader("127.0.0.4:8080")
+	leader, err = cluster.getLeader()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:8080", leader)
+	assert.False(t, cluster.leaderStale())
+
+	// step: a leader marked down has to be looked up again
+	cluster.setLeader("127.0.0.2:8080")
+	cluster.markDown("http://127.0.0.2:8080")
+	leader, err = cluster.getLeader()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:8080", leader)
+	assert.True(t, cluster.leaderStale())
+}
+
+func TestClusterClose(t *testing.T) {
+	cluster, err := newStandardCluster("http://127.0.0.1:8080,127.0.0.2:8080")
+	require.NoError(t, err)
+	cluster.healthCheckInterval = time.Hour
+
+	cluster.markDown("http://127.0.0.1:8080")
+	cluster.close()
+
+	done := make(chan struct{})
+	go func() {
+		cluster.wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("health checks did not stop")
+	}
+
+	// step: no health checks are started anymore
+	cluster.markDown("http://127.0.0.2:8080")
+	cluster.wait()
+	assert.Empty(t, cluster.activeMembers())
+}
+
 func TestValidClusterHosts(t *testing.T) {
 	cs := []struct {
 		URL    string
@@ -17,6 +17,7 @@ limitations under the License.
 package marathon
 
 import (
+	"crypto/tls"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -25,6 +26,26 @@ import (
 
 const defaultPollingWaitTime = 500 * time.Millisecond
 
+// eventsPollingWaitTimeFactor is how much less often the waits poll the API by default while the
+// event subscription receives the events completing them
+const eventsPollingWaitTimeFactor = 10
+
+const defaultMemberSourceInterval = 30 * time.Second
+
+// stableEventStreamDuration is how long the event stream must stay connected for its reconnection
+// not to be backed off
+const stableEventStreamDuration = time.Minute
+
+// defaultEventStreamBackoff returns the delays of the reconnections of the event stream
+func defaultEventStreamBackoff() *ExponentialRetryPolicy {
+	return &ExponentialRetryPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
 const defaultDCOSPath = "marathon"
 
 // EventsTransport describes which transport should be used to deliver Marathon events
@@ -40,6 +61,18 @@ type Config struct {
 	EventsPort int
 	// the interface we should be listening on for events
 	EventsInterface string
+	// EventsListenAddress is the address the callback server listens on, overriding
+	// EventsInterface and EventsPort, e.g. ":0" to listen on all interfaces on a free port
+	EventsListenAddress string
+	// EventsAdvertiseAddress is the host, with an optional port, advertised to Marathon for the
+	// callbacks, defaults to the address listened on. Unlike CallbackURL, the port listened on
+	// is used when it has no port.
+	EventsAdvertiseAddress string
+	// EventsTLSConfig serves the callbacks over TLS
+	EventsTLSConfig *tls.Config
+	// EventsExternalServer leaves serving the callbacks to a server of the application, which
+	// mounts CallbackHandler on the path of the subscription URL. CallbackURL must be set.
+	EventsExternalServer bool
 	// HTTPBasicAuthUser is the http basic auth
 	HTTPBasicAuthUser string
 	// HTTPBasicPassword is the http basic password
@@ -48,14 +81,60 @@ type Config struct {
 	CallbackURL string
 	// DCOSToken for DCOS environment, This will override the Authorization header
 	DCOSToken string
-	// LogOutput the output for debug log messages
+	// DCOSTokenProvider provides the DC/OS token of the requests, overriding DCOSToken. A
+	// request rejected with a 401 is retried once with a new token.
+	DCOSTokenProvider DCOSTokenProvider
+	// LogOutput the output for debug log messages, used when no Logger is set
 	LogOutput io.Writer
+	// Logger logs the messages of the client, defaults to logging debug messages to LogOutput
+	Logger Logger
 	// HTTPClient is the HTTP client
 	HTTPClient *http.Client
 	// HTTPSSEClient is the HTTP client used for SSE subscriptions, can't have client.Timeout set
 	HTTPSSEClient *http.Client
+	// EventStreamBackoff sets the delays of the reconnections of the SSE event stream once no
+	// member accepts it, or once it keeps failing. Its MaxAttempts and Idempotent are ignored.
+	// Defaults to delays growing from 1 second to 1 minute.
+	EventStreamBackoff *ExponentialRetryPolicy
+	// EventsLightPlanFormat asks Marathon for the light format of the deployment plans of the
+	// SSE events, which leaves out the original and target groups (Marathon >= 1.5)
+	EventsLightPlanFormat bool
+	// EventsRecorder, if set, gets the events received as JSON lines of RecordedEvent, which
+	// ReplayEvents reads back
+	EventsRecorder io.Writer
+	// TLS configures the TLS connections of both HTTP clients
+	TLS *TLSConfig
 	// wait time (in milliseconds) between repetitive requests to the API during polling
 	PollingWaitTime time.Duration
+	// EventsPollingWaitTime is the wait time between the requests to the API of the waits while the
+	// event subscription receives the events completing them, defaults to 10 times PollingWaitTime
+	EventsPollingWaitTime time.Duration
+	// RetryPolicy decides whether and when failed API requests are retried. When not set, a
+	// request failing on a network error or a 5xx response is retried right away on the next
+	// available member until all members are marked as down. With a CircuitBreaker, a 5xx
+	// response is only retried when it opens the circuit of the member, it is returned otherwise.
+	RetryPolicy RetryPolicy
+	// MemberSelector picks the member of the cluster requests are sent to, defaults to the
+	// first available member in the order of the URL
+	MemberSelector MemberSelector
+	// MemberSource discovers the members of the cluster at runtime. When set, the members are
+	// refreshed from it every MemberSourceInterval, and URL may be left empty to discover the
+	// initial members from it as well.
+	MemberSource MemberSource
+	// MemberSourceInterval is the interval the members are refreshed at, defaults to 30 seconds
+	MemberSourceInterval time.Duration
+	// Middlewares wrap every call made to the cluster, in order, the first one being the outermost
+	Middlewares []Middleware
+	// Metrics records the requests, member failures and events of the client, NewMemoryMetrics
+	// provides an implementation exporting them in the Prometheus text format
+	Metrics Metrics
+	// ReadLimits limits the rate and concurrency of the API requests reading state (GET and HEAD)
+	ReadLimits RequestLimits
+	// WriteLimits limits the rate and concurrency of the API requests changing state
+	WriteLimits RequestLimits
+	// CircuitBreaker opens the circuit of members with elevated 5xx or slow response rates. When
+	// not set, a member is marked as down on its first failure.
+	CircuitBreaker *CircuitBreaker
 }
 
 // NewDefaultConfig create a default client config
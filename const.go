@@ -32,6 +32,9 @@ const (
 	marathonAPIInfo         = marathonAPIVersion + "/info"
 	marathonAPILeader       = marathonAPIVersion + "/leader"
 	marathonAPIPing         = "ping"
+
+	/* --- response headers --- */
+	leaderHeader = "X-Marathon-Leader"
 )
 
 const (
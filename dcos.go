@@ -0,0 +1,334 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dcosLoginPath is the path of the login endpoint of the DC/OS access control service
+const dcosLoginPath = "/acs/api/v1/auth/login"
+
+// DCOSTokenProvider provides the DC/OS authentication token sent with every request
+type DCOSTokenProvider interface {
+	// Token returns a valid token, logging in when there is none
+	Token(ctx context.Context) (string, error)
+	// Invalidate discards the token after Marathon rejected it, so the next call to Token
+	// logs in again
+	Invalidate(token string)
+}
+
+// DCOSLoginError signals that logging in to DC/OS failed
+type DCOSLoginError struct {
+	// StatusCode is the HTTP status of the login response, zero when none was received
+	StatusCode int
+	// Message is the reason of the failure
+	Message string
+}
+
+// Error implements error
+func (e *DCOSLoginError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("DC/OS login failed with status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("DC/OS login failed: %s", e.Message)
+}
+
+// DCOSServiceAccount is the configuration of a DC/OS service account login
+type DCOSServiceAccount struct {
+	// UID is the ID of the service account
+	UID string
+	// PrivateKey is the PEM encoded RSA private key of the service account
+	PrivateKey []byte
+	// ACSURL is the URL of the DC/OS access control service, e.g. https://leader.mesos
+	ACSURL string
+	// HTTPClient is the HTTP client used to log in, defaults to the default HTTP client
+	HTTPClient *http.Client
+	// LoginTokenExpiry is the validity of the login token signed with the private key,
+	// defaults to 5 minutes
+	LoginTokenExpiry time.Duration
+	// RefreshBefore is how long before its expiry the authentication token is refreshed,
+	// defaults to 10 minutes
+	RefreshBefore time.Duration
+}
+
+// dcosServiceAccountProvider logs in to DC/OS with a service account and caches the token
+type dcosServiceAccountProvider struct {
+	sync.Mutex
+	account    DCOSServiceAccount
+	privateKey *rsa.PrivateKey
+	token      string
+	expiry     time.Time
+}
+
+// NewDCOSServiceAccountProvider returns a token provider logging in to DC/OS with the service
+// account. The token is cached, refreshed before it expires and after Marathon rejected it.
+func NewDCOSServiceAccountProvider(account DCOSServiceAccount) (DCOSTokenProvider, error) {
+	if account.UID == "" {
+		return nil, errors.New("the service account UID is missing")
+	}
+	if _, err := url.Parse(account.ACSURL); err != nil || account.ACSURL == "" {
+		return nil, fmt.Errorf("invalid ACS URL: '%s'", account.ACSURL)
+	}
+	privateKey, err := parseRSAPrivateKey(account.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.HTTPClient == nil {
+		account.HTTPClient = defaultHTTPClient
+	}
+	if account.LoginTokenExpiry == 0 {
+		account.LoginTokenExpiry = 5 * time.Minute
+	}
+	if account.RefreshBefore == 0 {
+		account.RefreshBefore = 10 * time.Minute
+	}
+
+	return &dcosServiceAccountProvider{account: account, privateKey: privateKey}, nil
+}
+
+// Token implements DCOSTokenProvider
+func (p *dcosServiceAccountProvider) Token(ctx context.Context) (string, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	// step: use the cached token until it is about to expire
+	if p.token != "" && (p.expiry.IsZero() || time.Now().Add(p.account.RefreshBefore).Before(p.expiry)) {
+		return p.token, nil
+	}
+
+	token, err := p.login(ctx)
+	if err != nil {
+		return "", err
+	}
+	p.token = token
+	p.expiry = jwtExpiry(token)
+
+	return token, nil
+}
+
+// Invalidate implements DCOSTokenProvider
+func (p *dcosServiceAccountProvider) Invalidate(token string) {
+	p.Lock()
+	defer p.Unlock()
+	if p.token == token {
+		p.token = ""
+	}
+}
+
+// login exchanges a login token signed with the private key for an authentication token
+func (p *dcosServiceAccountProvider) login(ctx context.Context) (string, error) {
+	loginToken, err := signJWT(p.privateKey, map[string]interface{}{
+		"uid": p.account.UID,
+		"exp": time.Now().Add(p.account.LoginTokenExpiry).Unix(),
+	})
+	if err != nil {
+		return "", &DCOSLoginError{Message: err.Error()}
+	}
+	body, err := json.Marshal(map[string]string{"uid": p.account.UID, "token": loginToken})
+	if err != nil {
+		return "", &DCOSLoginError{Message: err.Error()}
+	}
+
+	request, err := http.NewRequest("POST", strings.TrimSuffix(p.account.ACSURL, "/")+dcosLoginPath, bytes.NewReader(body))
+	if err != nil {
+		return "", &DCOSLoginError{Message: err.Error()}
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := p.account.HTTPClient.Do(request.WithContext(ctx))
+	if err != nil {
+		return "", &DCOSLoginError{Message: err.Error()}
+	}
+	defer response.Body.Close()
+
+	content, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", &DCOSLoginError{Message: err.Error()}
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", &DCOSLoginError{StatusCode: response.StatusCode, Message: string(content)}
+	}
+
+	var result struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(content, &result); err != nil || result.Token == "" {
+		return "", &DCOSLoginError{StatusCode: response.StatusCode, Message: "no token in the login response"}
+	}
+
+	return result.Token, nil
+}
+
+// doAuthorized performs the request with the token of the DC/OS token provider, if any. When
+// the token is rejected, it logs in again and retries the request once, if its body can be sent
+// again. The request of the call is left untouched, as it may be the one of a RoundTripper.
+func (rc *httpClient) doAuthorized(call *Call, do func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	provider := rc.config.DCOSTokenProvider
+	if provider == nil {
+		return do(call.Request)
+	}
+
+	request := call.Request
+	token, err := provider.Token(request.Context())
+	if err != nil {
+		return nil, err
+	}
+	response, err := do(withToken(request, token))
+	if err != nil || response.StatusCode != http.StatusUnauthorized {
+		return response, err
+	}
+
+	// step: the token was rejected, so log in again and retry with a fresh body
+	provider.Invalidate(token)
+	body, ok := requestBody(call)
+	if !ok {
+		return response, nil
+	}
+	if token, err = provider.Token(request.Context()); err != nil {
+		if body != nil {
+			body.Close()
+		}
+		return response, nil
+	}
+	response.Body.Close()
+	retry := withToken(request, token)
+	retry.Body = body
+	call.Request = retry
+
+	return do(retry)
+}
+
+// withToken returns a copy of the request carrying the token, the request and its header being
+// left untouched
+func withToken(request *http.Request, token string) *http.Request {
+	authorized := request.WithContext(request.Context())
+	authorized.Header = make(http.Header, len(request.Header)+1)
+	for key, values := range request.Header {
+		authorized.Header[key] = append([]string(nil), values...)
+	}
+	authorized.Header.Set("Authorization", "token="+token)
+	return authorized
+}
+
+// requestBody returns a fresh body of the request of the call, to send it again. It returns false
+// when the body was consumed and can not be rebuilt.
+func requestBody(call *Call) (io.ReadCloser, bool) {
+	request := call.Request
+	switch {
+	case call.Body != nil:
+		return ioutil.NopCloser(bytes.NewReader(call.Body)), true
+	case request.Body == nil || request.Body == http.NoBody:
+		return request.Body, true
+	case request.GetBody != nil:
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, false
+		}
+		return body, true
+	}
+	return nil, false
+}
+
+// isDCOSLoginError checks whether the error, possibly returned through an HTTP client, is a
+// failure to log in to DC/OS, which is no fault of the Marathon member
+func isDCOSLoginError(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	_, ok := err.(*DCOSLoginError)
+	return ok
+}
+
+// parseRSAPrivateKey parses a PEM encoded PKCS #1 or PKCS #8 RSA private key
+func parseRSAPrivateKey(content []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("the private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the private key: %s", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// signJWT creates a JWT with the claims, signed with the key using RS256
+func signJWT(key *rsa.PrivateKey, claims map[string]interface{}) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// jwtExpiry returns the expiry of a JWT, without verifying it, or the zero time when unknown
+func jwtExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0)
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeACS is a DC/OS access control service issuing tokens to a service account
+type fakeACS struct {
+	sync.Mutex
+	t         *testing.T
+	publicKey *rsa.PublicKey
+	validity  time.Duration
+	logins    int
+	server    *httptest.Server
+}
+
+func newFakeACS(t *testing.T, publicKey *rsa.PublicKey, validity time.Duration) *fakeACS {
+	acs := &fakeACS{t: t, publicKey: publicKey, validity: validity}
+	acs.server = httptest.NewServer(http.HandlerFunc(acs.login))
+	return acs
+}
+
+func (a *fakeACS) login(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		UID   string `json:"uid"`
+		Token string `json:"token"`
+	}
+	if !assert.Equal(a.t, dcosLoginPath, r.URL.Path) || !assert.NoError(a.t, json.NewDecoder(r.Body).Decode(&body)) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	// step: verify the login token was signed by the service account
+	parts := strings.Split(body.Token, ".")
+	require.Len(a.t, parts, 3)
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(a.t, err)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if body.UID != "marathon-client" || rsa.VerifyPKCS1v15(a.publicKey, crypto.SHA256, digest[:], signature) != nil {
+		http.Error(w, `{"title": "Invalid authentication"}`, http.StatusUnauthorized)
+		return
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	a.logins++
+	fmt.Fprintf(w, `{"token": "%s"}`, a.token(a.logins))
+}
+
+// token returns the authentication token of the given login
+func (a *fakeACS) token(login int) string {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"uid": "marathon-client",
+		"exp": time.Now().Add(a.validity).Unix(),
+		"n":   login,
+	})
+	return fmt.Sprintf("header.%s.signature", base64.RawURLEncoding.EncodeToString(payload))
+}
+
+func (a *fakeACS) loginCount() int {
+	a.Lock()
+	defer a.Unlock()
+	return a.logins
+}
+
+func newServiceAccountKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	return key, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+func TestDCOSServiceAccountProviderRefresh(t *testing.T) {
+	key, keyPEM := newServiceAccountKey(t)
+
+	// step: tokens expiring after the refresh margin are cached
+	acs := newFakeACS(t, &key.PublicKey, time.Hour)
+	defer acs.server.Close()
+	provider, err := NewDCOSServiceAccountProvider(DCOSServiceAccount{UID: "marathon-client", PrivateKey: keyPEM, ACSURL: acs.server.URL})
+	require.NoError(t, err)
+	first, err := provider.Token(context.Background())
+	require.NoError(t, err)
+	second, err := provider.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, acs.loginCount())
+
+	// step: invalidated tokens are replaced
+	provider.Invalidate(first)
+	third, err := provider.Token(context.Background())
+	require.NoError(t, err)
+	assert.NotEqual(t, first, third)
+	assert.Equal(t, 2, acs.loginCount())
+
+	// step: tokens about to expire are refreshed
+	expiring := newFakeACS(t, &key.PublicKey, 5*time.Minute)
+	defer expiring.server.Close()
+	provider, err = NewDCOSServiceAccountProvider(DCOSServiceAccount{UID: "marathon-client", PrivateKey: keyPEM, ACSURL: expiring.server.URL})
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = provider.Token(context.Background())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, expiring.loginCount())
+}
+
+func TestDCOSServiceAccountProviderInvalid(t *testing.T) {
+	key, keyPEM := newServiceAccountKey(t)
+	acs := newFakeACS(t, &key.PublicKey, time.Hour)
+	defer acs.server.Close()
+
+	_, err := NewDCOSServiceAccountProvider(DCOSServiceAccount{PrivateKey: keyPEM, ACSURL: acs.server.URL})
+	assert.Error(t, err)
+	_, err = NewDCOSServiceAccountProvider(DCOSServiceAccount{UID: "marathon-client", PrivateKey: []byte("garbage"), ACSURL: acs.server.URL})
+	assert.Error(t, err)
+
+	provider, err := NewDCOSServiceAccountProvider(DCOSServiceAccount{UID: "someone-else", PrivateKey: keyPEM, ACSURL: acs.server.URL})
+	require.NoError(t, err)
+	_, err = provider.Token(context.Background())
+	if assert.Error(t, err) {
+		loginErr, ok := err.(*DCOSLoginError)
+		if assert.True(t, ok) {
+			assert.Equal(t, http.StatusUnauthorized, loginErr.StatusCode)
+		}
+	}
+}
+
+func TestDCOSTokenRefreshOnUnauthorized(t *testing.T) {
+	key, keyPEM := newServiceAccountKey(t)
+	acs := newFakeACS(t, &key.PublicKey, time.Hour)
+	defer acs.server.Close()
+
+	// step: marathon only accepts the token of the latest login
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		n := jwtClaim(t, strings.TrimPrefix(r.Header.Get("Authorization"), "token="), "n")
+		if n != float64(acs.loginCount()) || acs.loginCount() < 2 {
+			http.Error(w, `{"message": "invalid token"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"id": "/group"}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewDCOSServiceAccountProvider(DCOSServiceAccount{UID: "marathon-client", PrivateKey: keyPEM, ACSURL: acs.server.URL})
+	require.NoError(t, err)
+	client, err := NewClient(Config{URL: server.URL, DCOSTokenProvider: provider})
+	require.NoError(t, err)
+	client.(*marathonClient).hosts.setLeader(server.URL)
+
+	require.NoError(t, client.CreateGroup(&Group{ID: "/group"}))
+	assert.Equal(t, 2, acs.loginCount())
+	if assert.Len(t, bodies, 2) {
+		assert.Equal(t, bodies[0], bodies[1])
+		assert.Contains(t, bodies[1], `"id":"/group"`)
+	}
+}
+
+// countingTokenProvider hands out a new token after each invalidation
+type countingTokenProvider struct {
+	sync.Mutex
+	logins int
+}
+
+func (p *countingTokenProvider) Token(ctx context.Context) (string, error) {
+	p.Lock()
+	defer p.Unlock()
+	if p.logins == 0 {
+		p.logins++
+	}
+	return fmt.Sprintf("token-%d", p.logins), nil
+}
+
+func (p *countingTokenProvider) Invalidate(token string) {
+	p.Lock()
+	defer p.Unlock()
+	p.logins++
+}
+
+func TestDCOSTokenRoundTripper(t *testing.T) {
+	// step: marathon only accepts the second token
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if r.Header.Get("Authorization") != "token=token-2" {
+			http.Error(w, `{"message": "invalid token"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	roundTrip := func(body io.Reader) (*http.Request, *http.Response) {
+		transport := &middlewareTransport{
+			client: &httpClient{config: Config{DCOSTokenProvider: &countingTokenProvider{}}},
+			base:   http.DefaultTransport,
+		}
+		request, err := http.NewRequest("POST", server.URL, body)
+		require.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+		response, err := transport.RoundTrip(request)
+		require.NoError(t, err)
+		response.Body.Close()
+		return request, response
+	}
+
+	// step: a body which can be rebuilt is sent again with the new token
+	bodies = nil
+	request, response := roundTrip(strings.NewReader(`{"id": "/app"}`))
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, []string{`{"id": "/app"}`, `{"id": "/app"}`}, bodies)
+	assert.Empty(t, request.Header.Get("Authorization"), "the request was modified")
+	assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+
+	// step: a body which was consumed is not sent again
+	bodies = nil
+	request, response = roundTrip(ioutil.NopCloser(io.MultiReader(strings.NewReader(`{"id": "/app"}`))))
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	assert.Equal(t, []string{`{"id": "/app"}`}, bodies)
+	assert.Empty(t, request.Header.Get("Authorization"), "the request was modified")
+}
+
+func TestDCOSLoginFailureKeepsMembersUp(t *testing.T) {
+	key, keyPEM := newServiceAccountKey(t)
+	acs := newFakeACS(t, &key.PublicKey, time.Hour)
+	defer acs.server.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"apps": []}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewDCOSServiceAccountProvider(DCOSServiceAccount{UID: "someone-else", PrivateKey: keyPEM, ACSURL: acs.server.URL})
+	require.NoError(t, err)
+	client, err := NewClient(Config{URL: server.URL, DCOSTokenProvider: provider})
+	require.NoError(t, err)
+
+	_, err = client.Applications(nil)
+	assert.IsType(t, &DCOSLoginError{}, err)
+	assert.Empty(t, client.(*marathonClient).hosts.nonActiveMembers())
+}
+
+// jwtClaim returns a claim of a JWT without verifying it
+func jwtClaim(t *testing.T, token, claim string) interface{} {
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	claims := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(payload, &claims))
+	return claims[claim]
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package marathon
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -68,8 +69,13 @@ type DeploymentPlan struct {
 
 // Deployments retrieves a list of current deployments
 func (r *marathonClient) Deployments() ([]*Deployment, error) {
+	return r.DeploymentsContext(context.Background())
+}
+
+// DeploymentsContext is like Deployments but uses the given context
+func (r *marathonClient) DeploymentsContext(ctx context.Context) ([]*Deployment, error) {
 	var deployments []*Deployment
-	err := r.apiGet(marathonAPIDeployments, nil, &deployments)
+	err := r.apiGet(ctx, marathonAPIDeployments, nil, &deployments)
 	if err != nil {
 		return nil, err
 	}
@@ -108,16 +114,21 @@ func (r *marathonClient) Deployments() ([]*Deployment, error) {
 // 	id:		the deployment id you wish to delete
 // 	force:	whether or not to force the deletion
 func (r *marathonClient) DeleteDeployment(id string, force bool) (*DeploymentID, error) {
+	return r.DeleteDeploymentContext(context.Background(), id, force)
+}
+
+// DeleteDeploymentContext is like DeleteDeployment but uses the given context
+func (r *marathonClient) DeleteDeploymentContext(ctx context.Context, id string, force bool) (*DeploymentID, error) {
 	path := fmt.Sprintf("%s/%s", marathonAPIDeployments, id)
 
 	// if force=true, no body is returned
 	if force {
 		path += "?force=true"
-		return nil, r.apiDelete(path, nil, nil)
+		return nil, r.apiDelete(ctx, path, nil, nil)
 	}
 
 	deployment := new(DeploymentID)
-	err := r.apiDelete(path, nil, deployment)
+	err := r.apiDelete(ctx, path, nil, deployment)
 
 	if err != nil {
 		return nil, err
@@ -129,7 +140,12 @@ func (r *marathonClient) DeleteDeployment(id string, force bool) (*DeploymentID,
 // HasDeployment checks to see if a deployment exists
 // 	id:		the deployment id you are looking for
 func (r *marathonClient) HasDeployment(id string) (bool, error) {
-	deployments, err := r.Deployments()
+	return r.HasDeploymentContext(context.Background(), id)
+}
+
+// HasDeploymentContext is like HasDeployment but uses the given context
+func (r *marathonClient) HasDeploymentContext(ctx context.Context, id string) (bool, error) {
+	deployments, err := r.DeploymentsContext(ctx)
 	if err != nil {
 		return false, err
 	}
@@ -141,34 +157,27 @@ func (r *marathonClient) HasDeployment(id string) (bool, error) {
 	return false, nil
 }
 
-// WaitOnDeployment waits on a deployment to finish
+// WaitOnDeployment waits on a deployment to finish, see WaitOnDeploymentContext
 //  version:		the version of the application
 // 	timeout:		the timeout to wait for the deployment to take, otherwise return an error
 func (r *marathonClient) WaitOnDeployment(id string, timeout time.Duration) error {
-	if found, err := r.HasDeployment(id); err != nil {
-		return err
-	} else if !found {
-		return nil
-	}
+	return r.WaitOnDeploymentContext(context.Background(), id, timeout)
+}
 
-	nowTime := time.Now()
-	stopTime := nowTime.Add(timeout)
+// WaitOnDeploymentContext is like WaitOnDeployment but uses the given context. It returns a
+// *DeploymentFailedError when the event subscription tells the deployment failed. A deployment
+// is done once it is gone, whether it succeeded or failed, so nil does not mean it succeeded:
+// the failure is missed without an event subscription, or when the deployment is gone before
+// its deployment_failed event arrives.
+func (r *marathonClient) WaitOnDeploymentContext(ctx context.Context, id string, timeout time.Duration) error {
 	if timeout <= 0 {
-		stopTime = nowTime.Add(time.Duration(900) * time.Second)
+		timeout = time.Duration(900) * time.Second
 	}
 
-	// step: a somewhat naive implementation, but it will work
-	for {
-		if time.Now().After(stopTime) {
-			return ErrTimeoutError
-		}
-		found, err := r.HasDeployment(id)
-		if err != nil {
-			return err
-		}
-		if !found {
-			return nil
-		}
-		time.Sleep(r.config.PollingWaitTime)
-	}
+	// step: the deployment is done once it is gone, its events tell whether it succeeded
+	waiter := newEventWaiter(0, func(*Event) bool { return false }, id)
+	return r.wait(ctx, timeout, waiter, func(ctx context.Context) (bool, error) {
+		found, err := r.HasDeploymentContext(ctx, id)
+		return !found, err
+	})
 }
@@ -0,0 +1,389 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FieldChange is a change of a field between two definitions
+type FieldChange struct {
+	// Path is the path of the field, e.g. container.docker.image, env.DEBUG or healthChecks[0].path,
+	// the elements of the lists of objects with an id or a name being keyed by it, e.g. apps[/web]
+	Path string
+	// Old is the value before the change, nil when the field is added
+	Old interface{}
+	// New is the value after the change, nil when the field is removed
+	New interface{}
+}
+
+// Diff is the list of changes between two definitions, in the order of the fields
+type Diff []FieldChange
+
+// String renders the diff as unified lines, - for the old values and + for the new ones
+func (d Diff) String() string {
+	var buffer bytes.Buffer
+	for _, change := range d {
+		if change.Old != nil {
+			fmt.Fprintf(&buffer, "- %s: %s\n", change.Path, renderDiffValue(change.Old))
+		}
+		if change.New != nil {
+			fmt.Fprintf(&buffer, "+ %s: %s\n", change.Path, renderDiffValue(change.New))
+		}
+	}
+	return buffer.String()
+}
+
+// definitionSchema tells how to normalize a definition before diffing it
+type definitionSchema struct {
+	// the fields populated by Marathon
+	ignored []string
+	// the values Marathon defaults the fields left out to, in order
+	defaults []fieldDefault
+	// the schemas of the definitions of the fields holding lists of them
+	lists map[string]*definitionSchema
+}
+
+// fieldDefault is the value Marathon defaults a field left out to. The path is made of the
+// fields, [] standing for every element of a list. The default of a field whose parent is left
+// out is skipped, but for an empty object default, which creates it.
+type fieldDefault struct {
+	path  string
+	value interface{}
+}
+
+var applicationSchema = &definitionSchema{
+	ignored: []string{
+		"version", "versionInfo", "tasks", "tasksRunning", "tasksStaged", "tasksHealthy", "tasksUnhealthy",
+		"taskStats", "deployments", "lastTaskFailure", "readinessCheckResults",
+	},
+	defaults: []fieldDefault{
+		{"instances", 1.0},
+		{"cpus", 1.0},
+		{"mem", 128.0},
+		{"disk", 0.0},
+		{"gpus", 0.0},
+		{"backoffSeconds", 1.0},
+		{"backoffFactor", 1.15},
+		{"maxLaunchDelaySeconds", 3600.0},
+		{"killSelection", "YOUNGEST_FIRST"},
+		{"requirePorts", false},
+		{"upgradeStrategy", map[string]interface{}{}},
+		{"upgradeStrategy.minimumHealthCapacity", 1.0},
+		{"upgradeStrategy.maximumOverCapacity", 1.0},
+		{"container.docker.forcePullImage", false},
+		{"container.docker.privileged", false},
+		{"healthChecks.[].ignoreHttp1xx", false},
+		{"readinessChecks.[].preserveLastResponse", false},
+		{"fetch.[].extract", true},
+		{"fetch.[].executable", false},
+		{"fetch.[].cache", false},
+	},
+}
+
+var podSchema = &definitionSchema{
+	ignored: []string{"version"},
+	defaults: []fieldDefault{
+		{"scaling", map[string]interface{}{}},
+		{"scaling.kind", "fixed"},
+		{"scaling.instances", 1.0},
+		{"containers.[].image.forcePull", false},
+		{"containers.[].artifacts.[].extract", true},
+		{"containers.[].artifacts.[].executable", false},
+		{"containers.[].artifacts.[].cache", false},
+	},
+}
+
+var groupSchema = &definitionSchema{
+	ignored: []string{"version"},
+}
+
+func init() {
+	groupSchema.lists = map[string]*definitionSchema{
+		"apps":   applicationSchema,
+		"groups": groupSchema,
+	}
+}
+
+// DiffApplications returns the changes of the definition of an application from a to b. The fields
+// populated by Marathon are left out, the null and empty values are taken as left out, and the
+// fields left out take the values Marathon defaults them to.
+func DiffApplications(a, b *Application) (Diff, error) {
+	return diffDefinitions(a, b, applicationSchema)
+}
+
+// DiffPods is like DiffApplications for the definitions of pods
+func DiffPods(a, b *Pod) (Diff, error) {
+	return diffDefinitions(a, b, podSchema)
+}
+
+// DiffGroups is like DiffApplications for the definitions of groups, diffing their applications
+// and groups by their ID
+func DiffGroups(a, b *Group) (Diff, error) {
+	return diffDefinitions(a, b, groupSchema)
+}
+
+// diffDefinitions returns the changes between the normalized JSON representations of the definitions
+func diffDefinitions(a, b interface{}, schema *definitionSchema) (Diff, error) {
+	before, err := normalizeDefinition(a, schema)
+	if err != nil {
+		return nil, err
+	}
+	after, err := normalizeDefinition(b, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := Diff{}
+	diffValues("", before, after, &diff)
+	return diff, nil
+}
+
+// normalizeDefinition returns the JSON representation of the definition, normalized by the schema
+func normalizeDefinition(definition interface{}, schema *definitionSchema) (map[string]interface{}, error) {
+	content, err := json.Marshal(definition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the definition, error: %s", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(content, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode the definition, error: %s", err)
+	}
+	if fields == nil {
+		fields = make(map[string]interface{})
+	}
+
+	return schema.normalize(fields), nil
+}
+
+// normalize removes the ignored fields and the empty values of the definition, and fills in the defaults
+func (s *definitionSchema) normalize(definition map[string]interface{}) map[string]interface{} {
+	for _, field := range s.ignored {
+		delete(definition, field)
+	}
+	for field, schema := range s.lists {
+		items, _ := definition[field].([]interface{})
+		for _, item := range items {
+			if object, ok := item.(map[string]interface{}); ok {
+				schema.normalize(object)
+			}
+		}
+	}
+
+	pruneEmpty(definition)
+	for _, field := range s.defaults {
+		setDefault(definition, strings.Split(field.path, "."), field.value)
+	}
+	return definition
+}
+
+// pruneEmpty removes the null and empty values of the objects recursively, as Marathon takes them
+// as left out. It returns nil when nothing is left of the value.
+func pruneEmpty(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if pruned := pruneEmpty(field); pruned == nil {
+				delete(v, key)
+			}
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+		// note: the elements are kept, their position matters
+		for _, item := range v {
+			if object, ok := item.(map[string]interface{}); ok {
+				pruneEmpty(object)
+			}
+		}
+	case string:
+		if v == "" {
+			return nil
+		}
+	case nil:
+		return nil
+	}
+	return value
+}
+
+// setDefault sets the field of the path to the value unless it is set
+func setDefault(value interface{}, path []string, defaultValue interface{}) {
+	if path[0] == "[]" {
+		items, _ := value.([]interface{})
+		for _, item := range items {
+			setDefault(item, path[1:], defaultValue)
+		}
+		return
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	field, found := object[path[0]]
+	if len(path) == 1 {
+		if !found {
+			// note: the objects are created afresh, the defaults are shared
+			if _, isObject := defaultValue.(map[string]interface{}); isObject {
+				defaultValue = make(map[string]interface{})
+			}
+			object[path[0]] = defaultValue
+		}
+		return
+	}
+	if found {
+		setDefault(field, path[1:], defaultValue)
+	}
+}
+
+// diffValues adds the changes between the values of the path to the diff
+func diffValues(path string, before, after interface{}, diff *Diff) {
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+
+	beforeObject, beforeIsObject := before.(map[string]interface{})
+	afterObject, afterIsObject := after.(map[string]interface{})
+	if beforeIsObject && afterIsObject {
+		keys := make(map[string]bool)
+		for key := range beforeObject {
+			keys[key] = true
+		}
+		for key := range afterObject {
+			keys[key] = true
+		}
+		for _, key := range sortedSet(keys) {
+			diffValues(joinDiffPath(path, key), beforeObject[key], afterObject[key], diff)
+		}
+		return
+	}
+
+	beforeList, beforeIsList := before.([]interface{})
+	afterList, afterIsList := after.([]interface{})
+	if beforeIsList && afterIsList {
+		diffLists(path, beforeList, afterList, diff)
+		return
+	}
+
+	*diff = append(*diff, FieldChange{Path: path, Old: before, New: after})
+}
+
+// diffLists adds the changes between the lists of the path to the diff, by the key of their elements
+// if they have one, by their position otherwise
+func diffLists(path string, before, after []interface{}, diff *Diff) {
+	key := listKey(before, after)
+	if key == "" {
+		for index := 0; index < len(before) || index < len(after); index++ {
+			var beforeItem, afterItem interface{}
+			if index < len(before) {
+				beforeItem = before[index]
+			}
+			if index < len(after) {
+				afterItem = after[index]
+			}
+			diffValues(fmt.Sprintf("%s[%d]", path, index), beforeItem, afterItem, diff)
+		}
+		return
+	}
+
+	beforeItems := make(map[string]interface{})
+	for _, item := range before {
+		beforeItems[item.(map[string]interface{})[key].(string)] = item
+	}
+	afterItems := make(map[string]interface{})
+	for _, item := range after {
+		afterItems[item.(map[string]interface{})[key].(string)] = item
+	}
+	keys := make(map[string]bool)
+	for id := range beforeItems {
+		keys[id] = true
+	}
+	for id := range afterItems {
+		keys[id] = true
+	}
+	for _, id := range sortedSet(keys) {
+		diffValues(fmt.Sprintf("%s[%s]", path, id), beforeItems[id], afterItems[id], diff)
+	}
+}
+
+// listKey returns the field keying the elements of the lists, id or name, if all have a distinct one
+func listKey(before, after []interface{}) string {
+	for _, key := range []string{"id", "name"} {
+		if keyedList(before, key) && keyedList(after, key) {
+			return key
+		}
+	}
+	return ""
+}
+
+// keyedList reports whether all the elements of the list are objects with a distinct value of the key
+func keyedList(list []interface{}, key string) bool {
+	found := make(map[string]bool)
+	for _, item := range list {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		value, ok := object[key].(string)
+		if !ok || value == "" || found[value] {
+			return false
+		}
+		found[value] = true
+	}
+	return true
+}
+
+// joinDiffPath returns the path of the field of the object of the path, quoting the keys which
+// would be ambiguous
+func joinDiffPath(path, key string) string {
+	if strings.ContainsAny(key, ".[]\" ") || key == "" {
+		return path + "[" + strconv.Quote(key) + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// sortedSet returns the keys of the set in order
+func sortedSet(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// renderDiffValue renders a value of a diff as JSON
+func renderDiffValue(value interface{}) string {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(content)
+}
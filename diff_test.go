@@ -0,0 +1,184 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeTestDefinition(t *testing.T, content string, definition interface{}) {
+	require.NoError(t, json.Unmarshal([]byte(content), definition))
+}
+
+func TestDiffApplicationsNormalizes(t *testing.T) {
+	deployed := new(Application)
+	decodeTestDefinition(t, `{
+		"id": "/web",
+		"cmd": "sleep 100",
+		"instances": 1,
+		"mem": 128,
+		"requirePorts": false,
+		"args": [],
+		"labels": {},
+		"dependencies": [],
+		"upgradeStrategy": {"minimumHealthCapacity": 1, "maximumOverCapacity": 1},
+		"version": "2018-01-01T00:00:00.000Z",
+		"versionInfo": {"lastConfigChangeAt": "2018-01-01T00:00:00.000Z"},
+		"tasks": [{"id": "web.1"}],
+		"tasksRunning": 1,
+		"deployments": [{"id": "deployment-1"}],
+		"lastTaskFailure": {"taskId": "web.0"}
+	}`, deployed)
+	wanted := NewDockerApplication().Name("/web").Command("sleep 100")
+	wanted.Container = nil
+	wanted.EmptyLabels()
+
+	diff, err := DiffApplications(deployed, wanted)
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+	assert.Equal(t, "", diff.String())
+}
+
+func TestDiffApplications(t *testing.T) {
+	before := new(Application)
+	decodeTestDefinition(t, `{
+		"id": "/web",
+		"cpus": 0.5,
+		"env": {"A": "1"},
+		"labels": {"team": "a", "com.example.tier": "front"},
+		"healthChecks": [{"protocol": "HTTP", "path": "/health"}]
+	}`, before)
+	after := new(Application)
+	decodeTestDefinition(t, `{
+		"id": "/web",
+		"cpus": 1,
+		"instances": 0,
+		"env": {"A": "1", "B": "2"},
+		"labels": {"team": "b"},
+		"healthChecks": [{"protocol": "HTTP", "path": "/ready"}]
+	}`, after)
+
+	diff, err := DiffApplications(before, after)
+	require.NoError(t, err)
+	assert.Equal(t, Diff{
+		{Path: "cpus", Old: 0.5, New: 1.0},
+		{Path: "env.B", New: "2"},
+		{Path: "healthChecks[0].path", Old: "/health", New: "/ready"},
+		{Path: "instances", Old: 1.0, New: 0.0},
+		{Path: `labels["com.example.tier"]`, Old: "front"},
+		{Path: "labels.team", Old: "a", New: "b"},
+	}, diff)
+	assert.Equal(t, `- cpus: 0.5
++ cpus: 1
++ env.B: "2"
+- healthChecks[0].path: "/health"
++ healthChecks[0].path: "/ready"
+- instances: 1
++ instances: 0
+- labels["com.example.tier"]: "front"
+- labels.team: "a"
++ labels.team: "b"
+`, diff.String())
+}
+
+func TestDiffApplicationsBooleans(t *testing.T) {
+	before := new(Application)
+	decodeTestDefinition(t, `{
+		"id": "/web",
+		"container": {"docker": {"image": "web", "privileged": false}},
+		"fetch": [{"uri": "https://example.com/web.tgz", "extract": true}]
+	}`, before)
+	after := new(Application)
+	decodeTestDefinition(t, `{
+		"id": "/web",
+		"requirePorts": false,
+		"container": {"docker": {"image": "web", "forcePullImage": true}},
+		"fetch": [{"uri": "https://example.com/web.tgz", "extract": false}]
+	}`, after)
+
+	// step: the booleans left out take their default, which is not always false
+	diff, err := DiffApplications(before, after)
+	require.NoError(t, err)
+	assert.Equal(t, Diff{
+		{Path: "container.docker.forcePullImage", Old: false, New: true},
+		{Path: "fetch[0].extract", Old: true, New: false},
+	}, diff)
+}
+
+func TestDiffGroups(t *testing.T) {
+	before := new(Group)
+	decodeTestDefinition(t, `{
+		"id": "/prod",
+		"apps": [
+			{"id": "/prod/api", "cmd": "api", "tasksRunning": 2},
+			{"id": "/prod/web", "cmd": "web", "version": "1"}
+		],
+		"groups": [{"id": "/prod/jobs", "apps": [{"id": "/prod/jobs/cron", "cmd": "cron"}]}]
+	}`, before)
+	after := new(Group)
+	decodeTestDefinition(t, `{
+		"id": "/prod",
+		"apps": [
+			{"id": "/prod/web", "cmd": "web", "version": "2"},
+			{"id": "/prod/worker", "cmd": "worker"}
+		],
+		"groups": [{"id": "/prod/jobs", "apps": [{"id": "/prod/jobs/cron", "cmd": "cron --verbose"}]}]
+	}`, after)
+
+	diff, err := DiffGroups(before, after)
+	require.NoError(t, err)
+	var paths []string
+	for _, change := range diff {
+		paths = append(paths, change.Path)
+	}
+	assert.Equal(t, []string{
+		"apps[/prod/api]",
+		"apps[/prod/worker]",
+		"groups[/prod/jobs].apps[/prod/jobs/cron].cmd",
+	}, paths)
+	assert.Nil(t, diff[0].New)
+	assert.Nil(t, diff[1].Old)
+}
+
+func TestDiffPods(t *testing.T) {
+	before := new(Pod)
+	decodeTestDefinition(t, `{
+		"id": "/pod",
+		"version": "1",
+		"containers": [
+			{"name": "web", "resources": {"cpus": 1, "mem": 128}},
+			{"name": "sidecar", "resources": {"cpus": 0.1, "mem": 32}}
+		]
+	}`, before)
+	after := new(Pod)
+	decodeTestDefinition(t, `{
+		"id": "/pod",
+		"scaling": {"kind": "fixed", "instances": 1},
+		"containers": [
+			{"name": "sidecar", "resources": {"cpus": 0.1, "mem": 32}},
+			{"name": "web", "resources": {"cpus": 2, "mem": 128}}
+		]
+	}`, after)
+
+	diff, err := DiffPods(before, after)
+	require.NoError(t, err)
+	assert.Equal(t, Diff{{Path: "containers[web].resources.cpus", Old: 1.0, New: 2.0}}, diff)
+}
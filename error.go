@@ -61,6 +61,17 @@ func newInvalidEndpointError(message string, args ...interface{}) error {
 	return &InvalidEndpointError{message: fmt.Sprintf(message, args...)}
 }
 
+// DeploymentFailedError signals that a deployment a wait was waiting on failed
+type DeploymentFailedError struct {
+	// ID is the identifier of the deployment
+	ID string
+}
+
+// Error implements error
+func (e *DeploymentFailedError) Error() string {
+	return fmt.Sprintf("the deployment %s failed", e.ID)
+}
+
 // APIError represents a generic API error.
 type APIError struct {
 	// ErrCode specifies the nature of the error.
@@ -0,0 +1,406 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// defaultHandlerQueueSize is the number of events queued for a handler before the dispatch blocks
+const defaultHandlerQueueSize = 100
+
+// HandlerOptions are the settings of an event handler
+type HandlerOptions struct {
+	// Concurrency is the number of events the handler handles at once, defaults to 1 which
+	// handles the events in order
+	Concurrency int
+	// QueueSize is the number of events queued for the handler, defaults to 100. The dispatch
+	// blocks while the queue is full.
+	QueueSize int
+}
+
+// EventDispatcherConfig is the configuration of an event dispatcher
+type EventDispatcherConfig struct {
+	// OnError is called with the errors returned by the handlers and their panics
+	OnError func(event *Event, err error)
+}
+
+// EventDispatcher dispatches the events to handlers registered per event type, sparing the
+// type assertions of the events. Each handler runs on its own goroutines, so a slow, failing
+// or panicking handler does not affect the others. The handlers share the events, which
+// must not be modified.
+type EventDispatcher struct {
+	sync.Mutex
+	config   EventDispatcherConfig
+	handlers map[int][]*eventHandler
+	running  bool
+}
+
+// eventHandler is a handler registered to a dispatcher
+type eventHandler struct {
+	handle  func(event *Event) error
+	options HandlerOptions
+	queue   chan *Event
+}
+
+// NewEventDispatcher creates an event dispatcher, see Run
+func NewEventDispatcher(config EventDispatcherConfig) *EventDispatcher {
+	if config.OnError == nil {
+		config.OnError = func(*Event, error) {}
+	}
+	return &EventDispatcher{
+		config:   config,
+		handlers: make(map[int][]*eventHandler),
+	}
+}
+
+// Handle registers a handler of the events of the type, e.g. 'status_update_event'
+func (d *EventDispatcher) Handle(eventType string, handler func(event *Event) error, options ...HandlerOptions) error {
+	id, found := eventTypeID(eventType)
+	if !found {
+		return fmt.Errorf("the event type: %s was not found or supported", eventType)
+	}
+	return d.register(id, handler, options)
+}
+
+// Filter returns the filter of the events the handlers are registered for, to be
+// given to AddEventsListener
+func (d *EventDispatcher) Filter() int {
+	d.Lock()
+	defer d.Unlock()
+	filter := 0
+	for id := range d.handlers {
+		filter |= id
+	}
+	return filter
+}
+
+// Run dispatches the events of the channel until the channel is closed or the context
+// is done, then waits for the handlers to finish the events queued. The handlers must be
+// registered before, ErrDispatcherRunning is returned if the dispatcher is already running.
+func (d *EventDispatcher) Run(ctx context.Context, events EventsChannel) error {
+	d.Lock()
+	if d.running {
+		d.Unlock()
+		return ErrDispatcherRunning
+	}
+	d.running = true
+	handlers := d.handlers
+	d.Unlock()
+
+	// step: start the workers of the handlers
+	var workers sync.WaitGroup
+	for _, registered := range handlers {
+		for _, handler := range registered {
+			handler.queue = make(chan *Event, handler.options.QueueSize)
+			for n := 0; n < handler.options.Concurrency; n++ {
+				workers.Add(1)
+				go func(handler *eventHandler) {
+					defer workers.Done()
+					for event := range handler.queue {
+						d.call(handler, event)
+					}
+				}(handler)
+			}
+		}
+	}
+
+	defer func() {
+		for _, registered := range handlers {
+			for _, handler := range registered {
+				close(handler.queue)
+			}
+		}
+		workers.Wait()
+
+		d.Lock()
+		d.running = false
+		d.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			for _, handler := range handlers[event.ID] {
+				select {
+				case handler.queue <- event:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// register adds a handler of the events with the ID, unless the dispatcher is running
+func (d *EventDispatcher) register(id int, handle func(event *Event) error, options []HandlerOptions) error {
+	var handlerOptions HandlerOptions
+	if len(options) > 0 {
+		handlerOptions = options[0]
+	}
+	if handlerOptions.Concurrency <= 0 {
+		handlerOptions.Concurrency = 1
+	}
+	if handlerOptions.QueueSize <= 0 {
+		handlerOptions.QueueSize = defaultHandlerQueueSize
+	}
+
+	d.Lock()
+	defer d.Unlock()
+	if d.running {
+		return ErrDispatcherRunning
+	}
+	d.handlers[id] = append(d.handlers[id], &eventHandler{handle: handle, options: handlerOptions})
+
+	return nil
+}
+
+// call calls the handler, reporting its error or panic
+func (d *EventDispatcher) call(handler *eventHandler, event *Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			d.config.OnError(event, fmt.Errorf("handler of %s panicked: %v", event.Name, r))
+		}
+	}()
+	if err := handler.handle(event); err != nil {
+		d.config.OnError(event, err)
+	}
+}
+
+// OnAPIRequest registers a handler of the 'api_post_event' events
+func (d *EventDispatcher) OnAPIRequest(handler func(event *EventAPIRequest) error, options ...HandlerOptions) error {
+	return d.register(EventIDAPIRequest, func(event *Event) error {
+		return handler(event.Event.(*EventAPIRequest))
+	}, options)
+}
+
+// OnStatusUpdate registers a handler of the 'status_update_event' events
+func (d *EventDispatcher) OnStatusUpdate(handler func(event *EventStatusUpdate) error, options ...HandlerOptions) error {
+	return d.register(EventIDStatusUpdate, func(event *Event) error {
+		return handler(event.Event.(*EventStatusUpdate))
+	}, options)
+}
+
+// OnAppTerminated registers a handler of the 'app_terminated_event' events
+func (d *EventDispatcher) OnAppTerminated(handler func(event *EventAppTerminated) error, options ...HandlerOptions) error {
+	return d.register(EventIDAppTerminated, func(event *Event) error {
+		return handler(event.Event.(*EventAppTerminated))
+	}, options)
+}
+
+// OnFrameworkMessage registers a handler of the 'framework_message_event' events
+func (d *EventDispatcher) OnFrameworkMessage(handler func(event *EventFrameworkMessage) error, options ...HandlerOptions) error {
+	return d.register(EventIDFrameworkMessage, func(event *Event) error {
+		return handler(event.Event.(*EventFrameworkMessage))
+	}, options)
+}
+
+// OnSubscription registers a handler of the 'subscribe_event' events
+func (d *EventDispatcher) OnSubscription(handler func(event *EventSubscription) error, options ...HandlerOptions) error {
+	return d.register(EventIDSubscription, func(event *Event) error {
+		return handler(event.Event.(*EventSubscription))
+	}, options)
+}
+
+// OnUnsubscription registers a handler of the 'unsubscribe_event' events
+func (d *EventDispatcher) OnUnsubscription(handler func(event *EventUnsubscription) error, options ...HandlerOptions) error {
+	return d.register(EventIDUnsubscribed, func(event *Event) error {
+		return handler(event.Event.(*EventUnsubscription))
+	}, options)
+}
+
+// OnStreamAttached registers a handler of the 'event_stream_attached' events
+func (d *EventDispatcher) OnStreamAttached(handler func(event *EventStreamAttached) error, options ...HandlerOptions) error {
+	return d.register(EventIDStreamAttached, func(event *Event) error {
+		return handler(event.Event.(*EventStreamAttached))
+	}, options)
+}
+
+// OnStreamDetached registers a handler of the 'event_stream_detached' events
+func (d *EventDispatcher) OnStreamDetached(handler func(event *EventStreamDetached) error, options ...HandlerOptions) error {
+	return d.register(EventIDStreamDetached, func(event *Event) error {
+		return handler(event.Event.(*EventStreamDetached))
+	}, options)
+}
+
+// OnAddHealthCheck registers a handler of the 'add_health_check_event' events
+func (d *EventDispatcher) OnAddHealthCheck(handler func(event *EventAddHealthCheck) error, options ...HandlerOptions) error {
+	return d.register(EventIDAddHealthCheck, func(event *Event) error {
+		return handler(event.Event.(*EventAddHealthCheck))
+	}, options)
+}
+
+// OnRemoveHealthCheck registers a handler of the 'remove_health_check_event' events
+func (d *EventDispatcher) OnRemoveHealthCheck(handler func(event *EventRemoveHealthCheck) error, options ...HandlerOptions) error {
+	return d.register(EventIDRemoveHealthCheck, func(event *Event) error {
+		return handler(event.Event.(*EventRemoveHealthCheck))
+	}, options)
+}
+
+// OnFailedHealthCheck registers a handler of the 'failed_health_check_event' events
+func (d *EventDispatcher) OnFailedHealthCheck(handler func(event *EventFailedHealthCheck) error, options ...HandlerOptions) error {
+	return d.register(EventIDFailedHealthCheck, func(event *Event) error {
+		return handler(event.Event.(*EventFailedHealthCheck))
+	}, options)
+}
+
+// OnHealthCheckChanged registers a handler of the 'health_status_changed_event' events
+func (d *EventDispatcher) OnHealthCheckChanged(handler func(event *EventHealthCheckChanged) error, options ...HandlerOptions) error {
+	return d.register(EventIDChangedHealthCheck, func(event *Event) error {
+		return handler(event.Event.(*EventHealthCheckChanged))
+	}, options)
+}
+
+// OnGroupChangeSuccess registers a handler of the 'group_change_success' events
+func (d *EventDispatcher) OnGroupChangeSuccess(handler func(event *EventGroupChangeSuccess) error, options ...HandlerOptions) error {
+	return d.register(EventIDGroupChangeSuccess, func(event *Event) error {
+		return handler(event.Event.(*EventGroupChangeSuccess))
+	}, options)
+}
+
+// OnGroupChangeFailed registers a handler of the 'group_change_failed' events
+func (d *EventDispatcher) OnGroupChangeFailed(handler func(event *EventGroupChangeFailed) error, options ...HandlerOptions) error {
+	return d.register(EventIDGroupChangeFailed, func(event *Event) error {
+		return handler(event.Event.(*EventGroupChangeFailed))
+	}, options)
+}
+
+// OnDeploymentSuccess registers a handler of the 'deployment_success' events
+func (d *EventDispatcher) OnDeploymentSuccess(handler func(event *EventDeploymentSuccess) error, options ...HandlerOptions) error {
+	return d.register(EventIDDeploymentSuccess, func(event *Event) error {
+		return handler(event.Event.(*EventDeploymentSuccess))
+	}, options)
+}
+
+// OnDeploymentFailed registers a handler of the 'deployment_failed' events
+func (d *EventDispatcher) OnDeploymentFailed(handler func(event *EventDeploymentFailed) error, options ...HandlerOptions) error {
+	return d.register(EventIDDeploymentFailed, func(event *Event) error {
+		return handler(event.Event.(*EventDeploymentFailed))
+	}, options)
+}
+
+// OnDeploymentInfo registers a handler of the 'deployment_info' events
+func (d *EventDispatcher) OnDeploymentInfo(handler func(event *EventDeploymentInfo) error, options ...HandlerOptions) error {
+	return d.register(EventIDDeploymentInfo, func(event *Event) error {
+		return handler(event.Event.(*EventDeploymentInfo))
+	}, options)
+}
+
+// OnDeploymentStepSuccess registers a handler of the 'deployment_step_success' events
+func (d *EventDispatcher) OnDeploymentStepSuccess(handler func(event *EventDeploymentStepSuccess) error, options ...HandlerOptions) error {
+	return d.register(EventIDDeploymentStepSuccess, func(event *Event) error {
+		return handler(event.Event.(*EventDeploymentStepSuccess))
+	}, options)
+}
+
+// OnDeploymentStepFailure registers a handler of the 'deployment_step_failure' events
+func (d *EventDispatcher) OnDeploymentStepFailure(handler func(event *EventDeploymentStepFailure) error, options ...HandlerOptions) error {
+	return d.register(EventIDDeploymentStepFailed, func(event *Event) error {
+		return handler(event.Event.(*EventDeploymentStepFailure))
+	}, options)
+}
+
+// OnPodCreated registers a handler of the 'pod_created_event' events
+func (d *EventDispatcher) OnPodCreated(handler func(event *EventPodCreated) error, options ...HandlerOptions) error {
+	return d.register(EventIDPodCreated, func(event *Event) error {
+		return handler(event.Event.(*EventPodCreated))
+	}, options)
+}
+
+// OnPodUpdated registers a handler of the 'pod_updated_event' events
+func (d *EventDispatcher) OnPodUpdated(handler func(event *EventPodUpdated) error, options ...HandlerOptions) error {
+	return d.register(EventIDPodUpdated, func(event *Event) error {
+		return handler(event.Event.(*EventPodUpdated))
+	}, options)
+}
+
+// OnPodDeleted registers a handler of the 'pod_deleted_event' events
+func (d *EventDispatcher) OnPodDeleted(handler func(event *EventPodDeleted) error, options ...HandlerOptions) error {
+	return d.register(EventIDPodDeleted, func(event *Event) error {
+		return handler(event.Event.(*EventPodDeleted))
+	}, options)
+}
+
+// OnInstanceChanged registers a handler of the 'instance_changed_event' events
+func (d *EventDispatcher) OnInstanceChanged(handler func(event *EventInstanceChanged) error, options ...HandlerOptions) error {
+	return d.register(EventIDInstanceChanged, func(event *Event) error {
+		return handler(event.Event.(*EventInstanceChanged))
+	}, options)
+}
+
+// OnInstanceHealthChanged registers a handler of the 'instance_health_changed_event' events
+func (d *EventDispatcher) OnInstanceHealthChanged(handler func(event *EventInstanceHealthChanged) error, options ...HandlerOptions) error {
+	return d.register(EventIDInstanceHealthChanged, func(event *Event) error {
+		return handler(event.Event.(*EventInstanceHealthChanged))
+	}, options)
+}
+
+// OnUnknownInstanceTerminated registers a handler of the 'unknown_instance_terminated_event' events
+func (d *EventDispatcher) OnUnknownInstanceTerminated(handler func(event *EventUnknownInstanceTerminated) error, options ...HandlerOptions) error {
+	return d.register(EventIDUnknownInstanceTerminated, func(event *Event) error {
+		return handler(event.Event.(*EventUnknownInstanceTerminated))
+	}, options)
+}
+
+// OnSchedulerRegistered registers a handler of the 'scheduler_registered_event' events
+func (d *EventDispatcher) OnSchedulerRegistered(handler func(event *EventSchedulerRegistered) error, options ...HandlerOptions) error {
+	return d.register(EventIDSchedulerRegistered, func(event *Event) error {
+		return handler(event.Event.(*EventSchedulerRegistered))
+	}, options)
+}
+
+// OnSchedulerReregistered registers a handler of the 'scheduler_reregistered_event' events
+func (d *EventDispatcher) OnSchedulerReregistered(handler func(event *EventSchedulerReregistered) error, options ...HandlerOptions) error {
+	return d.register(EventIDSchedulerReregistered, func(event *Event) error {
+		return handler(event.Event.(*EventSchedulerReregistered))
+	}, options)
+}
+
+// OnSchedulerDisconnected registers a handler of the 'scheduler_disconnected_event' events
+func (d *EventDispatcher) OnSchedulerDisconnected(handler func(event *EventSchedulerDisconnected) error, options ...HandlerOptions) error {
+	return d.register(EventIDSchedulerDisconnected, func(event *Event) error {
+		return handler(event.Event.(*EventSchedulerDisconnected))
+	}, options)
+}
+
+// OnStreamConnected registers a handler of the 'stream_connected_event' events
+func (d *EventDispatcher) OnStreamConnected(handler func(event *EventStreamConnected) error, options ...HandlerOptions) error {
+	return d.register(EventIDStreamConnected, func(event *Event) error {
+		return handler(event.Event.(*EventStreamConnected))
+	}, options)
+}
+
+// OnStreamDisconnected registers a handler of the 'stream_disconnected_event' events
+func (d *EventDispatcher) OnStreamDisconnected(handler func(event *EventStreamDisconnected) error, options ...HandlerOptions) error {
+	return d.register(EventIDStreamDisconnected, func(event *Event) error {
+		return handler(event.Event.(*EventStreamDisconnected))
+	}, options)
+}
+
+// OnUnknown registers a handler of the events of the types which are neither modelled nor registered
+func (d *EventDispatcher) OnUnknown(handler func(event *EventUnknown) error, options ...HandlerOptions) error {
+	return d.register(EventIDUnknown, func(event *Event) error {
+		return handler(event.Event.(*EventUnknown))
+	}, options)
+}
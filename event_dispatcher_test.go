@@ -0,0 +1,202 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEvent(t *testing.T, eventType string) *Event {
+	event, err := GetEvent(eventType)
+	require.NoError(t, err)
+	return event
+}
+
+func TestEventDispatcherTypedHandlers(t *testing.T) {
+	dispatcher := NewEventDispatcher(EventDispatcherConfig{})
+	var updates []string
+	var deployments []string
+	dispatcher.OnStatusUpdate(func(event *EventStatusUpdate) error {
+		updates = append(updates, event.TaskID)
+		return nil
+	})
+	dispatcher.OnDeploymentSuccess(func(event *EventDeploymentSuccess) error {
+		deployments = append(deployments, event.ID)
+		return nil
+	})
+	var names []string
+	require.NoError(t, dispatcher.Handle("status_update_event", func(event *Event) error {
+		names = append(names, event.Name)
+		return nil
+	}))
+	assert.Error(t, dispatcher.Handle("unknown_event", func(*Event) error { return nil }))
+	assert.Equal(t, EventIDStatusUpdate|EventIDDeploymentSuccess, dispatcher.Filter())
+
+	events := make(EventsChannel, 10)
+	for _, taskID := range []string{"task1", "task2", "task3"} {
+		event := newTestEvent(t, "status_update_event")
+		event.Event.(*EventStatusUpdate).TaskID = taskID
+		events <- event
+	}
+	deployment := newTestEvent(t, "deployment_success")
+	deployment.Event.(*EventDeploymentSuccess).ID = "deployment"
+	events <- deployment
+	events <- newTestEvent(t, "deployment_failed")
+	close(events)
+	assert.NoError(t, dispatcher.Run(context.Background(), events))
+
+	assert.Equal(t, []string{"task1", "task2", "task3"}, updates)
+	assert.Equal(t, []string{"deployment"}, deployments)
+	assert.Equal(t, []string{"status_update_event", "status_update_event", "status_update_event"}, names)
+}
+
+func TestEventDispatcherIsolation(t *testing.T) {
+	var failures []string
+	var lock sync.Mutex
+	dispatcher := NewEventDispatcher(EventDispatcherConfig{
+		OnError: func(event *Event, err error) {
+			lock.Lock()
+			defer lock.Unlock()
+			failures = append(failures, err.Error())
+		},
+	})
+	var handled int32
+	dispatcher.OnAppTerminated(func(*EventAppTerminated) error {
+		return errors.New("failed")
+	})
+	dispatcher.OnAppTerminated(func(*EventAppTerminated) error {
+		panic("boom")
+	})
+	dispatcher.OnAppTerminated(func(*EventAppTerminated) error {
+		atomic.AddInt32(&handled, 1)
+		return nil
+	})
+
+	events := make(EventsChannel, 2)
+	events <- newTestEvent(t, "app_terminated_event")
+	events <- newTestEvent(t, "app_terminated_event")
+	close(events)
+	assert.NoError(t, dispatcher.Run(context.Background(), events))
+
+	assert.Equal(t, int32(2), handled)
+	assert.Len(t, failures, 4)
+	assert.Contains(t, failures, "failed")
+	assert.Contains(t, failures, "handler of app_terminated_event panicked: boom")
+}
+
+func TestEventDispatcherConcurrency(t *testing.T) {
+	dispatcher := NewEventDispatcher(EventDispatcherConfig{})
+	var running, maxRunning int32
+	release := make(chan struct{})
+	dispatcher.OnStatusUpdate(func(*EventStatusUpdate) error {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+				break
+			}
+		}
+		<-release
+		atomic.AddInt32(&running, -1)
+		return nil
+	}, HandlerOptions{Concurrency: 3})
+
+	// step: a slow handler does not hold the others back
+	var deployments int32
+	dispatcher.OnDeploymentInfo(func(*EventDeploymentInfo) error {
+		atomic.AddInt32(&deployments, 1)
+		return nil
+	})
+
+	events := make(EventsChannel)
+	done := make(chan struct{})
+	go func() {
+		assert.NoError(t, dispatcher.Run(context.Background(), events))
+		close(done)
+	}()
+	for n := 0; n < 5; n++ {
+		events <- newTestEvent(t, "status_update_event")
+	}
+	events <- newTestEvent(t, "deployment_info")
+
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if atomic.LoadInt32(&running) == 3 && atomic.LoadInt32(&deployments) == 1 {
+			break
+		}
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&running))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&deployments))
+
+	close(release)
+	close(events)
+	<-done
+	assert.Equal(t, int32(3), atomic.LoadInt32(&maxRunning))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&running))
+}
+
+func TestEventDispatcherStopsOnContext(t *testing.T) {
+	dispatcher := NewEventDispatcher(EventDispatcherConfig{})
+	dispatcher.OnStatusUpdate(func(*EventStatusUpdate) error { return nil })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		dispatcher.Run(ctx, make(EventsChannel))
+		close(done)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the dispatcher did not stop")
+	}
+}
+
+func TestEventDispatcherRunning(t *testing.T) {
+	dispatcher := NewEventDispatcher(EventDispatcherConfig{})
+	require.NoError(t, dispatcher.OnStatusUpdate(func(*EventStatusUpdate) error { return nil }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- dispatcher.Run(ctx, make(EventsChannel))
+	}()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		dispatcher.Lock()
+		running := dispatcher.running
+		dispatcher.Unlock()
+		if running {
+			break
+		}
+	}
+
+	assert.Equal(t, ErrDispatcherRunning, dispatcher.Run(ctx, make(EventsChannel)))
+	assert.Equal(t, ErrDispatcherRunning, dispatcher.OnStatusUpdate(func(*EventStatusUpdate) error { return nil }))
+	assert.Equal(t, ErrDispatcherRunning, dispatcher.Handle("deployment_info", func(*Event) error { return nil }))
+
+	cancel()
+	assert.NoError(t, <-done)
+	assert.NoError(t, dispatcher.OnStatusUpdate(func(*EventStatusUpdate) error { return nil }))
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"strings"
+)
+
+// EventPredicate reports whether an event is wanted by a listener
+type EventPredicate func(event *Event) bool
+
+// MatchAppIDPrefix matches the events about the applications and pods whose ID starts with the prefix
+func MatchAppIDPrefix(prefix string) EventPredicate {
+	return func(event *Event) bool {
+		for _, id := range eventAppIDs(event) {
+			if strings.HasPrefix(id, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchGroup matches the events about the group, or the groups, applications and pods below it
+func MatchGroup(groupID string) EventPredicate {
+	groupID = normalizeEventID(groupID)
+	inGroup := func(id string) bool {
+		id = normalizeEventID(id)
+		return groupID == "/" || id == groupID || strings.HasPrefix(id, groupID+"/")
+	}
+
+	return func(event *Event) bool {
+		switch e := event.Event.(type) {
+		case *EventGroupChangeSuccess:
+			return inGroup(e.GroupID)
+		case *EventGroupChangeFailed:
+			return inGroup(e.GroupID)
+		}
+		for _, id := range eventAppIDs(event) {
+			if inGroup(id) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchTaskStates matches the status updates of the tasks in one of the states, e.g. TASK_RUNNING
+func MatchTaskStates(states ...string) EventPredicate {
+	wanted := make(map[string]bool, len(states))
+	for _, state := range states {
+		wanted[state] = true
+	}
+
+	return func(event *Event) bool {
+		if e, ok := event.Event.(*EventStatusUpdate); ok {
+			return wanted[e.TaskStatus]
+		}
+		return false
+	}
+}
+
+// eventAppIDs returns the IDs of the applications and pods an event is about
+func eventAppIDs(event *Event) []string {
+	switch e := event.Event.(type) {
+	case *EventStatusUpdate:
+		return []string{e.AppID}
+	case *EventAppTerminated:
+		return []string{e.AppID}
+	case *EventAddHealthCheck:
+		return []string{e.AppID}
+	case *EventRemoveHealthCheck:
+		return []string{e.AppID}
+	case *EventFailedHealthCheck:
+		return []string{e.AppID}
+	case *EventHealthCheckChanged:
+		return []string{e.AppID}
+	case *EventInstanceChanged:
+		return []string{e.RunSpecID}
+	case *EventInstanceHealthChanged:
+		return []string{e.RunSpecID}
+	case *EventUnknownInstanceTerminated:
+		return []string{e.RunSpecID}
+	case *EventPodCreated:
+		return podIDsFromURI(e.URI)
+	case *EventPodUpdated:
+		return podIDsFromURI(e.URI)
+	case *EventPodDeleted:
+		return podIDsFromURI(e.URI)
+	case *EventAPIRequest:
+		if e.AppDefinition != nil {
+			return []string{e.AppDefinition.ID}
+		}
+	case *EventDeploymentSuccess:
+		return deploymentPlanAppIDs(e.Plan, nil)
+	case *EventDeploymentInfo:
+		return deploymentPlanAppIDs(e.Plan, e.CurrentStep)
+	case *EventDeploymentStepSuccess:
+		return deploymentPlanAppIDs(e.Plan, e.CurrentStep)
+	case *EventDeploymentStepFailure:
+		return deploymentPlanAppIDs(e.Plan, e.CurrentStep)
+	}
+	return nil
+}
+
+// deploymentPlanAppIDs returns the IDs of the applications the steps of a deployment act on
+func deploymentPlanAppIDs(plan *DeploymentPlan, current *StepActions) []string {
+	steps := []*StepActions{current}
+	if plan != nil {
+		steps = append(steps, plan.Steps...)
+	}
+
+	var ids []string
+	found := make(map[string]bool)
+	for _, step := range steps {
+		if step == nil {
+			continue
+		}
+		for _, action := range step.Actions {
+			if action.App != "" && !found[action.App] {
+				found[action.App] = true
+				ids = append(ids, action.App)
+			}
+		}
+	}
+	return ids
+}
+
+// podIDsFromURI returns the ID of the pod of the URI of a pod event, if any, as the creations have none
+func podIDsFromURI(uri string) []string {
+	if id := podIDFromURI(uri); id != "" {
+		return []string{id}
+	}
+	return nil
+}
+
+// podIDFromURI returns the ID of the pod of the URI of a pod event, or an empty string
+func podIDFromURI(uri string) string {
+	id := strings.TrimPrefix(uri, "/"+marathonAPIPods)
+	if index := strings.Index(id, "::"); index >= 0 {
+		id = id[:index]
+	}
+	if id == "/" {
+		return ""
+	}
+	return id
+}
+
+// normalizeEventID returns the ID with a leading slash and no trailing one
+func normalizeEventID(id string) string {
+	return "/" + strings.Trim(id, "/")
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventPredicates(t *testing.T) {
+	statusUpdate := &Event{ID: EventIDStatusUpdate, Event: &EventStatusUpdate{AppID: "/prod/web/frontend", TaskStatus: "TASK_RUNNING"}}
+	instanceChanged := &Event{ID: EventIDInstanceChanged, Event: &EventInstanceChanged{RunSpecID: "/prod/api"}}
+	podUpdated := &Event{ID: EventIDPodUpdated, Event: &EventPodUpdated{URI: "/v2/pods/prod/web/pod::status"}}
+	podCreated := &Event{ID: EventIDPodCreated, Event: &EventPodCreated{URI: "/v2/pods"}}
+	groupChanged := &Event{ID: EventIDGroupChangeSuccess, Event: &EventGroupChangeSuccess{GroupID: "/prod/web"}}
+	deploymentInfo := &Event{ID: EventIDDeploymentInfo, Event: &EventDeploymentInfo{
+		Plan: &DeploymentPlan{Steps: []*StepActions{newTestStepActions("/prod/web/frontend"), newTestStepActions("/dev/api")}},
+	}}
+
+	cases := []struct {
+		name      string
+		predicate EventPredicate
+		event     *Event
+		matched   bool
+	}{
+		{"app prefix of a status update", MatchAppIDPrefix("/prod/web"), statusUpdate, true},
+		{"app prefix of another app", MatchAppIDPrefix("/dev"), statusUpdate, false},
+		{"app prefix of an instance", MatchAppIDPrefix("/prod/api"), instanceChanged, true},
+		{"app prefix of a pod", MatchAppIDPrefix("/prod/web/pod"), podUpdated, true},
+		{"app prefix of a pod creation", MatchAppIDPrefix("/"), podCreated, false},
+		{"app prefix of a deployment step", MatchAppIDPrefix("/dev/"), deploymentInfo, true},
+		{"app prefix of a group", MatchAppIDPrefix("/prod"), groupChanged, false},
+		{"group of an app", MatchGroup("/prod/web"), statusUpdate, true},
+		{"group with slashes", MatchGroup("prod/web/"), statusUpdate, true},
+		{"group sharing a prefix", MatchGroup("/prod/we"), statusUpdate, false},
+		{"group itself", MatchGroup("/prod/web"), groupChanged, true},
+		{"subgroup", MatchGroup("/prod/web/frontend"), groupChanged, false},
+		{"root group", MatchGroup("/"), instanceChanged, true},
+		{"group of a deployment", MatchGroup("/dev"), deploymentInfo, true},
+		{"task state", MatchTaskStates("TASK_FAILED", "TASK_RUNNING"), statusUpdate, true},
+		{"other task state", MatchTaskStates("TASK_FAILED"), statusUpdate, false},
+		{"task state of another event", MatchTaskStates("TASK_RUNNING"), instanceChanged, false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.matched, c.predicate(c.event), c.name)
+	}
+}
+
+func TestListenerPredicates(t *testing.T) {
+	endpoint := newFakeMarathonEndpoint(t, nil)
+	defer endpoint.Close()
+	client := endpoint.Client.(*marathonClient)
+
+	client.Lock()
+	channel := client.addEventsListener(EventIDStatusUpdate|EventIDStreamLifecycle, ListenerOptions{
+		Predicates: []EventPredicate{
+			MatchAppIDPrefix("/prod"),
+			func(event *Event) bool {
+				update, ok := event.Event.(*EventStatusUpdate)
+				return ok && update.Host == "agent-1"
+			},
+		},
+	})
+	client.Unlock()
+
+	client.dispatchEvent(&Event{ID: EventIDStatusUpdate, Event: &EventStatusUpdate{AppID: "/dev/app", Host: "agent-1"}})
+	client.dispatchEvent(&Event{ID: EventIDStatusUpdate, Event: &EventStatusUpdate{AppID: "/prod/app", Host: "agent-2"}})
+	client.dispatchEvent(&Event{ID: EventIDStatusUpdate, Event: &EventStatusUpdate{AppID: "/prod/app", Host: "agent-1", TaskID: "matched"}})
+	client.dispatchEvent(&Event{ID: EventIDStreamConnected, Event: &EventStreamConnected{}})
+
+	event := <-channel
+	if assert.IsType(t, &EventStatusUpdate{}, event.Event) {
+		assert.Equal(t, "matched", event.Event.(*EventStatusUpdate).TaskID)
+	}
+	event = <-channel
+	assert.IsType(t, &EventStreamConnected{}, event.Event)
+}
+
+func newTestStepActions(apps ...string) *StepActions {
+	step := new(StepActions)
+	for _, app := range apps {
+		step.Actions = append(step.Actions, struct {
+			Action string `json:"action"`
+			Type   string `json:"type"`
+			App    string `json:"app"`
+		}{Action: "RestartApplication", App: app})
+	}
+	return step
+}
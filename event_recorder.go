@@ -0,0 +1,110 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// RecordedEvent is an event of a recording, written as a JSON line to Config.EventsRecorder
+type RecordedEvent struct {
+	// Time is when the event was received
+	Time time.Time `json:"time"`
+	// EventType is the type of the event
+	EventType string `json:"eventType"`
+	// Data is the event as received from Marathon
+	Data json.RawMessage `json:"data,omitempty"`
+	// Raw is the event as received from Marathon when it is not valid JSON, Data is empty then
+	Raw string `json:"raw,omitempty"`
+}
+
+// content returns the event as received from Marathon
+func (e *RecordedEvent) content() string {
+	if len(e.Data) == 0 {
+		return e.Raw
+	}
+	return string(e.Data)
+}
+
+// recordEvent writes the event received to the recorder, if any. The event type is empty for
+// the events it failed to decode from.
+func (r *marathonClient) recordEvent(eventType, content string) {
+	if r.config.EventsRecorder == nil {
+		return
+	}
+
+	recorded := &RecordedEvent{
+		Time:      time.Now().UTC(),
+		EventType: eventType,
+		Data:      json.RawMessage(content),
+	}
+	line, err := json.Marshal(recorded)
+	if err != nil {
+		// step: the event is not valid JSON, it is kept as is
+		recorded.Data, recorded.Raw = nil, content
+		if line, err = json.Marshal(recorded); err != nil {
+			r.logger.Warn("failed to encode the recorded event", "event_type", eventType, "error", err)
+			return
+		}
+	}
+
+	r.recorderLock.Lock()
+	defer r.recorderLock.Unlock()
+	if _, err := r.config.EventsRecorder.Write(append(line, '\n')); err != nil {
+		r.logger.Warn("failed to record the event", "event_type", eventType, "error", err)
+	}
+}
+
+// ReplayEvents delivers the events of a recording to the listeners, decoding them as the events
+// received from Marathon. The speed sets the pace, 1 being the original pace, 10 ten times faster,
+// and 0 or less no delay between the events at all. The events which fail to decode are skipped.
+func (r *marathonClient) ReplayEvents(ctx context.Context, recording io.Reader, speed float64) error {
+	decoder := json.NewDecoder(recording)
+	var last time.Time
+	for line := 1; ; line++ {
+		recorded := new(RecordedEvent)
+		if err := decoder.Decode(recorded); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read the recorded event %d, error: %s", line, err)
+		}
+
+		// step: wait for the time between the events, scaled by the speed
+		if speed > 0 && !last.IsZero() {
+			if delay := time.Duration(float64(recorded.Time.Sub(last)) / speed); delay > 0 {
+				timer := time.NewTimer(delay)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				}
+			}
+		}
+		last = recorded.Time
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// note: the errors are logged and counted in the metrics already
+		r.processEvent(recorded.content(), false)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplayEvents(t *testing.T) {
+	recording := new(bytes.Buffer)
+	config := NewDefaultConfig()
+	config.EventsRecorder = recording
+	recorder := newFakeMarathonEndpoint(t, &configContainer{client: &config})
+	defer recorder.Close()
+
+	filter := 0
+	for _, c := range testCases {
+		require.NoError(t, recorder.Client.(*marathonClient).handleEvent(c.source))
+		id, _ := eventTypeID(c.name)
+		filter |= id
+	}
+	assert.Error(t, recorder.Client.(*marathonClient).handleEvent("not json"))
+	assert.Error(t, recorder.Client.(*marathonClient).handleEvent(`{"eventType": "deployment_info"} trailing`))
+
+	// step: every event received is recorded on a line of its own, as is
+	lines := strings.Split(strings.TrimSpace(recording.String()), "\n")
+	require.Len(t, lines, len(testCases)+2)
+	recorded := new(RecordedEvent)
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), recorded))
+	assert.Equal(t, testCases[0].name, recorded.EventType)
+	assert.False(t, recorded.Time.IsZero())
+	malformed := new(RecordedEvent)
+	require.NoError(t, json.Unmarshal([]byte(lines[len(testCases)]), malformed))
+	assert.Equal(t, &RecordedEvent{Time: malformed.Time, Raw: "not json"}, malformed)
+	malformed = new(RecordedEvent)
+	require.NoError(t, json.Unmarshal([]byte(lines[len(testCases)+1]), malformed))
+	assert.Equal(t, "deployment_info", malformed.EventType)
+	assert.Equal(t, `{"eventType": "deployment_info"} trailing`, malformed.content())
+
+	// step: the replay delivers the same events, without recording them again
+	replayer := newFakeMarathonEndpoint(t, nil)
+	defer replayer.Close()
+	client := replayer.Client.(*marathonClient)
+	client.Lock()
+	events := client.addEventsListener(filter, ListenerOptions{})
+	client.Unlock()
+
+	replayed := bytes.NewBufferString(recording.String())
+	require.NoError(t, client.ReplayEvents(context.Background(), replayed, 0))
+	for _, c := range testCases {
+		select {
+		case event := <-events:
+			assert.Equal(t, c.name, event.Name)
+			assert.Equal(t, c.expectation, event.Event)
+		case <-time.After(eventPublishTimeout):
+			require.Fail(t, "did not receive event in time", c.name)
+		}
+	}
+}
+
+func TestReplayEventsPace(t *testing.T) {
+	endpoint := newFakeMarathonEndpoint(t, nil)
+	defer endpoint.Close()
+	client := endpoint.Client
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	recording := ""
+	for i := 0; i < 3; i++ {
+		line, err := json.Marshal(&RecordedEvent{
+			Time:      start.Add(time.Duration(i) * 100 * time.Millisecond),
+			EventType: "deployment_info",
+			Data:      json.RawMessage(`{"eventType": "deployment_info"}`),
+		})
+		require.NoError(t, err)
+		recording += string(line) + "\n"
+	}
+
+	begin := time.Now()
+	require.NoError(t, client.ReplayEvents(context.Background(), strings.NewReader(recording), 1))
+	assert.True(t, time.Since(begin) >= 200*time.Millisecond, "the original pace is kept")
+
+	begin = time.Now()
+	require.NoError(t, client.ReplayEvents(context.Background(), strings.NewReader(recording), 10))
+	elapsed := time.Since(begin)
+	assert.True(t, elapsed >= 20*time.Millisecond && elapsed < 200*time.Millisecond, "the pace is accelerated")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, client.ReplayEvents(ctx, strings.NewReader(recording), 1))
+
+	err := client.ReplayEvents(context.Background(), strings.NewReader(recording+"not json\n"), 0)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "recorded event 4")
+	}
+}
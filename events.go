@@ -16,7 +16,12 @@ limitations under the License.
 
 package marathon
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
 
 // EventType is a wrapper for a marathon event
 type EventType struct {
@@ -62,38 +67,164 @@ const (
 	EventIDDeploymentStepFailed
 	// EventIDAppTerminated is the event listener ID for the corresponding event.
 	EventIDAppTerminated
+	// EventIDStreamConnected is the event listener ID of the pseudo event delivered when the event stream connects.
+	EventIDStreamConnected
+	// EventIDStreamDisconnected is the event listener ID of the pseudo event delivered when the event stream disconnects.
+	EventIDStreamDisconnected
+	// EventIDUnknown is the event listener ID for the events of the types which are neither modelled nor registered.
+	EventIDUnknown
+	// EventIDPodCreated is the event listener ID for the corresponding event.
+	EventIDPodCreated
+	// EventIDPodUpdated is the event listener ID for the corresponding event.
+	EventIDPodUpdated
+	// EventIDPodDeleted is the event listener ID for the corresponding event.
+	EventIDPodDeleted
+	// EventIDInstanceChanged is the event listener ID for the corresponding event.
+	EventIDInstanceChanged
+	// EventIDInstanceHealthChanged is the event listener ID for the corresponding event.
+	EventIDInstanceHealthChanged
+	// EventIDUnknownInstanceTerminated is the event listener ID for the corresponding event.
+	EventIDUnknownInstanceTerminated
+	// EventIDSchedulerRegistered is the event listener ID for the corresponding event.
+	EventIDSchedulerRegistered
+	// EventIDSchedulerReregistered is the event listener ID for the corresponding event.
+	EventIDSchedulerReregistered
+	// EventIDSchedulerDisconnected is the event listener ID for the corresponding event.
+	EventIDSchedulerDisconnected
 	//EventIDApplications comprises all listener IDs for application events.
 	EventIDApplications = EventIDStatusUpdate | EventIDChangedHealthCheck | EventIDFailedHealthCheck | EventIDAppTerminated
 	//EventIDSubscriptions comprises all listener IDs for subscription events.
 	EventIDSubscriptions = EventIDSubscription | EventIDUnsubscribed | EventIDStreamAttached | EventIDStreamDetached
+	// EventIDPods comprises all listener IDs for pod events.
+	EventIDPods = EventIDPodCreated | EventIDPodUpdated | EventIDPodDeleted
+	// EventIDInstances comprises all listener IDs for instance events.
+	EventIDInstances = EventIDInstanceChanged | EventIDInstanceHealthChanged | EventIDUnknownInstanceTerminated
+	// EventIDScheduler comprises all listener IDs for the events of the scheduler.
+	EventIDScheduler = EventIDSchedulerRegistered | EventIDSchedulerReregistered | EventIDSchedulerDisconnected |
+		EventIDFrameworkMessage
+	// EventIDStreamLifecycle comprises all listener IDs for the connection events of the event stream.
+	EventIDStreamLifecycle = EventIDStreamConnected | EventIDStreamDisconnected
 )
 
 var (
 	eventTypesMap map[string]int
+	// the decoders of the event types registered at runtime
+	eventDecoders map[string]EventDecoder
+	// the listener ID of the last event type modelled or registered at runtime, the next one
+	// takes the following bit while it fits in an int
+	lastEventID = EventIDSchedulerDisconnected
+	// guards the event types, which may be registered at runtime
+	eventTypesLock sync.RWMutex
 )
 
 func init() {
+	eventDecoders = make(map[string]EventDecoder)
 	eventTypesMap = map[string]int{
-		"api_post_event":              EventIDAPIRequest,
-		"status_update_event":         EventIDStatusUpdate,
-		"framework_message_event":     EventIDFrameworkMessage,
-		"subscribe_event":             EventIDSubscription,
-		"unsubscribe_event":           EventIDUnsubscribed,
-		"event_stream_attached":       EventIDStreamAttached,
-		"event_stream_detached":       EventIDStreamDetached,
-		"add_health_check_event":      EventIDAddHealthCheck,
-		"remove_health_check_event":   EventIDRemoveHealthCheck,
-		"failed_health_check_event":   EventIDFailedHealthCheck,
-		"health_status_changed_event": EventIDChangedHealthCheck,
-		"group_change_success":        EventIDGroupChangeSuccess,
-		"group_change_failed":         EventIDGroupChangeFailed,
-		"deployment_success":          EventIDDeploymentSuccess,
-		"deployment_failed":           EventIDDeploymentFailed,
-		"deployment_info":             EventIDDeploymentInfo,
-		"deployment_step_success":     EventIDDeploymentStepSuccess,
-		"deployment_step_failure":     EventIDDeploymentStepFailed,
-		"app_terminated_event":        EventIDAppTerminated,
+		"api_post_event":                    EventIDAPIRequest,
+		"status_update_event":               EventIDStatusUpdate,
+		"framework_message_event":           EventIDFrameworkMessage,
+		"subscribe_event":                   EventIDSubscription,
+		"unsubscribe_event":                 EventIDUnsubscribed,
+		"event_stream_attached":             EventIDStreamAttached,
+		"event_stream_detached":             EventIDStreamDetached,
+		"add_health_check_event":            EventIDAddHealthCheck,
+		"remove_health_check_event":         EventIDRemoveHealthCheck,
+		"failed_health_check_event":         EventIDFailedHealthCheck,
+		"health_status_changed_event":       EventIDChangedHealthCheck,
+		"group_change_success":              EventIDGroupChangeSuccess,
+		"group_change_failed":               EventIDGroupChangeFailed,
+		"deployment_success":                EventIDDeploymentSuccess,
+		"deployment_failed":                 EventIDDeploymentFailed,
+		"deployment_info":                   EventIDDeploymentInfo,
+		"deployment_step_success":           EventIDDeploymentStepSuccess,
+		"deployment_step_failure":           EventIDDeploymentStepFailed,
+		"app_terminated_event":              EventIDAppTerminated,
+		"stream_connected_event":            EventIDStreamConnected,
+		"stream_disconnected_event":         EventIDStreamDisconnected,
+		"pod_created_event":                 EventIDPodCreated,
+		"pod_updated_event":                 EventIDPodUpdated,
+		"pod_deleted_event":                 EventIDPodDeleted,
+		"instance_changed_event":            EventIDInstanceChanged,
+		"instance_health_changed_event":     EventIDInstanceHealthChanged,
+		"unknown_instance_terminated_event": EventIDUnknownInstanceTerminated,
+		"scheduler_registered_event":        EventIDSchedulerRegistered,
+		"scheduler_reregistered_event":      EventIDSchedulerReregistered,
+		"scheduler_disconnected_event":      EventIDSchedulerDisconnected,
+	}
+}
+
+// EventDecoder decodes the raw JSON of an event
+type EventDecoder func(data []byte) (interface{}, error)
+
+// RegisterEventType registers an event type which is not modelled by the client, returning its
+// event listener ID. Its events are delivered with the value returned by the decoder.
+func RegisterEventType(eventType string, decoder EventDecoder) (int, error) {
+	eventTypesLock.Lock()
+	defer eventTypesLock.Unlock()
+
+	if _, found := eventTypesMap[eventType]; found {
+		return 0, fmt.Errorf("the event type: %s is already registered", eventType)
+	}
+	// note: the shift overflows into the sign bit once the bits of an int run out, which leaves
+	// none to register on 32-bit platforms
+	id := lastEventID << 1
+	if id <= 0 {
+		return 0, fmt.Errorf("unable to register the event type: %s, no event listener ID is left", eventType)
+	}
+	lastEventID = id
+	eventTypesMap[eventType] = id
+	eventDecoders[eventType] = decoder
+
+	return id, nil
+}
+
+// eventTypeID returns the event listener ID of the event type
+func eventTypeID(eventType string) (int, bool) {
+	eventTypesLock.RLock()
+	defer eventTypesLock.RUnlock()
+	id, found := eventTypesMap[eventType]
+	return id, found
+}
+
+// eventTypes returns the event listener IDs of all event types
+func eventTypes() map[string]int {
+	eventTypesLock.RLock()
+	defer eventTypesLock.RUnlock()
+	types := make(map[string]int, len(eventTypesMap))
+	for eventType, id := range eventTypesMap {
+		types[eventType] = id
+	}
+	return types
+}
+
+// decodeEvent decodes the raw JSON of an event of the type. The events of unknown types are
+// decoded as *EventUnknown.
+func decodeEvent(eventType string, data []byte) (*Event, error) {
+	eventTypesLock.RLock()
+	decoder, found := eventDecoders[eventType]
+	eventTypesLock.RUnlock()
+
+	if found {
+		id, _ := eventTypeID(eventType)
+		decoded, err := decoder(data)
+		if err != nil {
+			return nil, err
+		}
+		return &Event{ID: id, Name: eventType, Event: decoded}, nil
+	}
+
+	event, err := GetEvent(eventType)
+	if err != nil {
+		return &Event{
+			ID:    EventIDUnknown,
+			Name:  eventType,
+			Event: &EventUnknown{EventType: eventType, Data: json.RawMessage(data)},
+		}, nil
+	}
+	if err := json.Unmarshal(data, event.Event); err != nil {
+		return nil, err
 	}
+	return event, nil
 }
 
 //
@@ -310,11 +441,127 @@ type EventDeploymentStepFailure struct {
 	Plan        *DeploymentPlan `json:"plan"`
 }
 
-// GetEvent returns allocated empty event object which corresponds to provided event type
+/* --- Pods --- */
+
+// EventPodCreated describes a 'pod_created_event' event.
+type EventPodCreated struct {
+	EventType string `json:"eventType"`
+	Timestamp string `json:"timestamp"`
+	ClientIP  string `json:"clientIp"`
+	URI       string `json:"uri"`
+}
+
+// EventPodUpdated describes a 'pod_updated_event' event.
+type EventPodUpdated struct {
+	EventType string `json:"eventType"`
+	Timestamp string `json:"timestamp"`
+	ClientIP  string `json:"clientIp"`
+	URI       string `json:"uri"`
+}
+
+// EventPodDeleted describes a 'pod_deleted_event' event.
+type EventPodDeleted struct {
+	EventType string `json:"eventType"`
+	Timestamp string `json:"timestamp"`
+	ClientIP  string `json:"clientIp"`
+	URI       string `json:"uri"`
+}
+
+/* --- Instances --- */
+
+// EventInstanceChanged describes an 'instance_changed_event' event.
+type EventInstanceChanged struct {
+	EventType      string `json:"eventType"`
+	Timestamp      string `json:"timestamp"`
+	InstanceID     string `json:"instanceId"`
+	Condition      string `json:"condition"`
+	RunSpecID      string `json:"runSpecId"`
+	RunSpecVersion string `json:"runSpecVersion"`
+	AgentID        string `json:"agentId"`
+	Host           string `json:"host"`
+}
+
+// EventInstanceHealthChanged describes an 'instance_health_changed_event' event.
+type EventInstanceHealthChanged struct {
+	EventType      string `json:"eventType"`
+	Timestamp      string `json:"timestamp"`
+	InstanceID     string `json:"instanceId"`
+	RunSpecID      string `json:"runSpecId"`
+	RunSpecVersion string `json:"runSpecVersion"`
+	// Healthy is nil while the health of the instance is unknown
+	Healthy *bool `json:"healthy"`
+}
+
+// EventUnknownInstanceTerminated describes an 'unknown_instance_terminated_event' event.
+type EventUnknownInstanceTerminated struct {
+	EventType  string `json:"eventType"`
+	Timestamp  string `json:"timestamp"`
+	InstanceID string `json:"instanceId"`
+	RunSpecID  string `json:"runSpecId"`
+	Condition  string `json:"condition"`
+}
+
+/* --- Scheduler --- */
+
+// EventSchedulerRegistered describes a 'scheduler_registered_event' event.
+type EventSchedulerRegistered struct {
+	EventType   string `json:"eventType"`
+	Timestamp   string `json:"timestamp"`
+	FrameworkID string `json:"frameworkId"`
+	Master      string `json:"master"`
+}
+
+// EventSchedulerReregistered describes a 'scheduler_reregistered_event' event.
+type EventSchedulerReregistered struct {
+	EventType string `json:"eventType"`
+	Timestamp string `json:"timestamp"`
+	MasterID  string `json:"masterId"`
+}
+
+// EventSchedulerDisconnected describes a 'scheduler_disconnected_event' event.
+type EventSchedulerDisconnected struct {
+	EventType string `json:"eventType"`
+	Timestamp string `json:"timestamp"`
+}
+
+/* --- Event Stream Lifecycle --- */
+
+// EventStreamConnected describes a 'stream_connected_event' event. It is not sent by Marathon
+// but delivered by the client when its SSE event stream connects to a member.
+type EventStreamConnected struct {
+	EventType string `json:"eventType"`
+	Timestamp string `json:"timestamp"`
+	Member    string `json:"member"`
+	// Gap is how long the stream was disconnected, during which events may have been
+	// missed, zero on the first connection
+	Gap time.Duration `json:"gap"`
+}
+
+// EventStreamDisconnected describes a 'stream_disconnected_event' event. It is not sent by
+// Marathon but delivered by the client when its SSE event stream disconnects from a member.
+type EventStreamDisconnected struct {
+	EventType string `json:"eventType"`
+	Timestamp string `json:"timestamp"`
+	Member    string `json:"member"`
+	Error     string `json:"error"`
+}
+
+/* --- Unknown Events --- */
+
+// EventUnknown describes an event of a type which is neither modelled nor registered
+// with RegisterEventType
+type EventUnknown struct {
+	EventType string `json:"eventType"`
+	// Data is the raw JSON of the event
+	Data json.RawMessage `json:"data"`
+}
+
+// GetEvent returns allocated empty event object which corresponds to provided event type. The event
+// types registered with RegisterEventType are not supported.
 //		eventType:			the type of Marathon event
 func GetEvent(eventType string) (*Event, error) {
 	// step: check it's supported
-	id, found := eventTypesMap[eventType]
+	id, found := eventTypeID(eventType)
 	if found {
 		event := new(Event)
 		event.ID = id
@@ -358,6 +605,30 @@ func GetEvent(eventType string) (*Event, error) {
 			event.Event = new(EventDeploymentStepFailure)
 		case "app_terminated_event":
 			event.Event = new(EventAppTerminated)
+		case "stream_connected_event":
+			event.Event = new(EventStreamConnected)
+		case "stream_disconnected_event":
+			event.Event = new(EventStreamDisconnected)
+		case "pod_created_event":
+			event.Event = new(EventPodCreated)
+		case "pod_updated_event":
+			event.Event = new(EventPodUpdated)
+		case "pod_deleted_event":
+			event.Event = new(EventPodDeleted)
+		case "instance_changed_event":
+			event.Event = new(EventInstanceChanged)
+		case "instance_health_changed_event":
+			event.Event = new(EventInstanceHealthChanged)
+		case "unknown_instance_terminated_event":
+			event.Event = new(EventUnknownInstanceTerminated)
+		case "scheduler_registered_event":
+			event.Event = new(EventSchedulerRegistered)
+		case "scheduler_reregistered_event":
+			event.Event = new(EventSchedulerReregistered)
+		case "scheduler_disconnected_event":
+			event.Event = new(EventSchedulerDisconnected)
+		default:
+			return nil, fmt.Errorf("the event type: %s is registered, it has no event object", eventType)
 		}
 		return event, nil
 	}
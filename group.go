@@ -17,6 +17,7 @@ limitations under the License.
 package marathon
 
 import (
+	"context"
 	"fmt"
 	"time"
 )
@@ -86,8 +87,13 @@ func (r *Group) App(application *Application) *Group {
 
 // Groups retrieves a list of all the groups from marathon
 func (r *marathonClient) Groups() (*Groups, error) {
+	return r.GroupsContext(context.Background())
+}
+
+// GroupsContext is like Groups but uses the given context
+func (r *marathonClient) GroupsContext(ctx context.Context) (*Groups, error) {
 	groups := new(Groups)
-	if err := r.apiGet(marathonAPIGroups, "", groups); err != nil {
+	if err := r.apiGet(ctx, marathonAPIGroups, "", groups); err != nil {
 		return nil, err
 	}
 	return groups, nil
@@ -96,8 +102,13 @@ func (r *marathonClient) Groups() (*Groups, error) {
 // Group retrieves the configuration of a specific group from marathon
 //		name:			the identifier for the group
 func (r *marathonClient) Group(name string) (*Group, error) {
+	return r.GroupContext(context.Background(), name)
+}
+
+// GroupContext is like Group but uses the given context
+func (r *marathonClient) GroupContext(ctx context.Context, name string) (*Group, error) {
 	group := new(Group)
-	if err := r.apiGet(fmt.Sprintf("%s/%s", marathonAPIGroups, trimRootPath(name)), nil, group); err != nil {
+	if err := r.apiGet(ctx, fmt.Sprintf("%s/%s", marathonAPIGroups, trimRootPath(name)), nil, group); err != nil {
 		return nil, err
 	}
 	return group, nil
@@ -106,12 +117,17 @@ func (r *marathonClient) Group(name string) (*Group, error) {
 // GroupsBy retrieves a list of all the groups from marathon by embed options
 //		opts:		GetGroupOpts request payload
 func (r *marathonClient) GroupsBy(opts *GetGroupOpts) (*Groups, error) {
+	return r.GroupsByContext(context.Background(), opts)
+}
+
+// GroupsByContext is like GroupsBy but uses the given context
+func (r *marathonClient) GroupsByContext(ctx context.Context, opts *GetGroupOpts) (*Groups, error) {
 	path, err := addOptions(marathonAPIGroups, opts)
 	if err != nil {
 		return nil, err
 	}
 	groups := new(Groups)
-	if err := r.apiGet(path, "", groups); err != nil {
+	if err := r.apiGet(ctx, path, "", groups); err != nil {
 		return nil, err
 	}
 	return groups, nil
@@ -121,12 +137,17 @@ func (r *marathonClient) GroupsBy(opts *GetGroupOpts) (*Groups, error) {
 //		name:			the identifier for the group
 //		opts:			GetGroupOpts request payload
 func (r *marathonClient) GroupBy(name string, opts *GetGroupOpts) (*Group, error) {
+	return r.GroupByContext(context.Background(), name, opts)
+}
+
+// GroupByContext is like GroupBy but uses the given context
+func (r *marathonClient) GroupByContext(ctx context.Context, name string, opts *GetGroupOpts) (*Group, error) {
 	path, err := addOptions(fmt.Sprintf("%s/%s", marathonAPIGroups, trimRootPath(name)), opts)
 	if err != nil {
 		return nil, err
 	}
 	group := new(Group)
-	if err := r.apiGet(path, nil, group); err != nil {
+	if err := r.apiGet(ctx, path, nil, group); err != nil {
 		return nil, err
 	}
 	return group, nil
@@ -135,8 +156,13 @@ func (r *marathonClient) GroupBy(name string, opts *GetGroupOpts) (*Group, error
 // HasGroup checks if the group exists in marathon
 // 		name:			the identifier for the group
 func (r *marathonClient) HasGroup(name string) (bool, error) {
+	return r.HasGroupContext(context.Background(), name)
+}
+
+// HasGroupContext is like HasGroup but uses the given context
+func (r *marathonClient) HasGroupContext(ctx context.Context, name string) (bool, error) {
 	path := fmt.Sprintf("%s/%s", marathonAPIGroups, trimRootPath(name))
-	err := r.apiGet(path, "", nil)
+	err := r.apiGet(ctx, path, "", nil)
 	if err != nil {
 		if apiErr, ok := err.(*APIError); ok && apiErr.ErrCode == ErrCodeNotFound {
 			return false, nil
@@ -149,70 +175,80 @@ func (r *marathonClient) HasGroup(name string) (bool, error) {
 // CreateGroup creates a new group in marathon
 //		group:			a pointer the Group structure defining the group
 func (r *marathonClient) CreateGroup(group *Group) error {
-	return r.ApiPost(marathonAPIGroups, group, nil)
+	return r.CreateGroupContext(context.Background(), group)
+}
+
+// CreateGroupContext is like CreateGroup but uses the given context
+func (r *marathonClient) CreateGroupContext(ctx context.Context, group *Group) error {
+	return r.apiPost(ctx, marathonAPIGroups, group, nil)
 }
 
 // WaitOnGroup waits for all the applications in a group to be deployed
 // 		group:			the identifier for the group
 //		timeout: 		a duration of time to wait before considering it failed (all tasks in all apps running defined as deployed)
 func (r *marathonClient) WaitOnGroup(name string, timeout time.Duration) error {
-	err := deadline(timeout, func(stop_channel chan bool) error {
-		var flick atomicSwitch
-		go func() {
-			<-stop_channel
-			close(stop_channel)
-			flick.SwitchOn()
-		}()
-		for !flick.IsSwitched() {
-			if group, err := r.Group(name); err != nil {
-				continue
-			} else {
-				allRunning := true
-				// for each of the application, check if the tasks and running
-				for _, appID := range group.Apps {
-					// Arrrgghhh!! .. so we can't use application instances from the Application struct like with app wait on as it
-					// appears the instance count is not set straight away!! .. it defaults to zero and changes probably at the
-					// dependencies gets deployed. Which is probably how it internally handles dependencies ..
-					// step: grab the application
-					application, err := r.Application(appID.ID)
-					if err != nil {
-						allRunning = false
-						break
-					}
-
-					if application.Tasks == nil {
-						allRunning = false
-					} else if len(application.Tasks) != *appID.Instances {
-						allRunning = false
-					} else if application.TasksRunning != *appID.Instances {
-						allRunning = false
-					} else if len(application.DeploymentIDs()) > 0 {
-						allRunning = false
-					}
-				}
-				// has anyone toggle the flag?
-				if allRunning {
-					return nil
-				}
-			}
-			time.Sleep(r.config.PollingWaitTime)
-		}
-		return nil
+	return r.WaitOnGroupContext(context.Background(), name, timeout)
+}
+
+// WaitOnGroupContext is like WaitOnGroup but uses the given context
+func (r *marathonClient) WaitOnGroupContext(ctx context.Context, name string, timeout time.Duration) error {
+	waiter := newEventWaiter(EventIDStatusUpdate, MatchGroup(name))
+	return r.wait(ctx, timeout, waiter, func(ctx context.Context) (bool, error) {
+		return r.groupDeployed(ctx, name, waiter), nil
 	})
+}
+
+// groupDeployed reports whether all the tasks of the applications of the group are running, adding
+// their deployments to the waiter
+func (r *marathonClient) groupDeployed(ctx context.Context, name string, waiter *eventWaiter) bool {
+	group, err := r.GroupContext(ctx, name)
+	if err != nil {
+		return false
+	}
+
+	// for each of the application, check if the tasks and running
+	deployed := true
+	for _, appID := range group.Apps {
+		// Arrrgghhh!! .. so we can't use application instances from the Application struct like with app wait on as it
+		// appears the instance count is not set straight away!! .. it defaults to zero and changes probably at the
+		// dependencies gets deployed. Which is probably how it internally handles dependencies ..
+		// step: grab the application
+		application, err := r.ApplicationContext(ctx, appID.ID)
+		if err != nil {
+			return false
+		}
+		for _, deployment := range application.DeploymentIDs() {
+			waiter.addDeployments(deployment.DeploymentID)
+		}
 
-	return err
+		if application.Tasks == nil {
+			deployed = false
+		} else if len(application.Tasks) != *appID.Instances {
+			deployed = false
+		} else if application.TasksRunning != *appID.Instances {
+			deployed = false
+		} else if len(application.DeploymentIDs()) > 0 {
+			deployed = false
+		}
+	}
+	return deployed
 }
 
 // DeleteGroup deletes a group from marathon
 //		name:			the identifier for the group
 //		force:			used to force the delete operation in case of blocked deployment
 func (r *marathonClient) DeleteGroup(name string, force bool) (*DeploymentID, error) {
+	return r.DeleteGroupContext(context.Background(), name, force)
+}
+
+// DeleteGroupContext is like DeleteGroup but uses the given context
+func (r *marathonClient) DeleteGroupContext(ctx context.Context, name string, force bool) (*DeploymentID, error) {
 	version := new(DeploymentID)
 	path := fmt.Sprintf("%s/%s", marathonAPIGroups, trimRootPath(name))
 	if force {
 		path += "?force=true"
 	}
-	if err := r.apiDelete(path, nil, version); err != nil {
+	if err := r.apiDelete(ctx, path, nil, version); err != nil {
 		return nil, err
 	}
 
@@ -224,12 +260,17 @@ func (r *marathonClient) DeleteGroup(name string, force bool) (*DeploymentID, er
 //		group:  		the group structure with the new params
 //		force:			used to force the update operation in case of blocked deployment
 func (r *marathonClient) UpdateGroup(name string, group *Group, force bool) (*DeploymentID, error) {
+	return r.UpdateGroupContext(context.Background(), name, group, force)
+}
+
+// UpdateGroupContext is like UpdateGroup but uses the given context
+func (r *marathonClient) UpdateGroupContext(ctx context.Context, name string, group *Group, force bool) (*DeploymentID, error) {
 	deploymentID := new(DeploymentID)
 	path := fmt.Sprintf("%s/%s", marathonAPIGroups, trimRootPath(name))
 	if force {
 		path += "?force=true"
 	}
-	if err := r.apiPut(path, group, deploymentID); err != nil {
+	if err := r.apiPut(ctx, path, group, deploymentID); err != nil {
 		return nil, err
 	}
 
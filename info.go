@@ -16,6 +16,8 @@ limitations under the License.
 
 package marathon
 
+import "context"
+
 // Info is the detailed stats returned from marathon info
 type Info struct {
 	EventSubscriber struct {
@@ -66,8 +68,13 @@ type Info struct {
 
 // Info retrieves the info stats from marathon
 func (r *marathonClient) Info() (*Info, error) {
+	return r.InfoContext(context.Background())
+}
+
+// InfoContext is like Info but uses the given context
+func (r *marathonClient) InfoContext(ctx context.Context) (*Info, error) {
 	info := new(Info)
-	if err := r.apiGet(marathonAPIInfo, nil, info); err != nil {
+	if err := r.apiGet(ctx, marathonAPIInfo, nil, info); err != nil {
 		return nil, err
 	}
 
@@ -76,25 +83,37 @@ func (r *marathonClient) Info() (*Info, error) {
 
 // Leader retrieves the current marathon leader node
 func (r *marathonClient) Leader() (string, error) {
+	return r.LeaderContext(context.Background())
+}
+
+// LeaderContext is like Leader but uses the given context
+func (r *marathonClient) LeaderContext(ctx context.Context) (string, error) {
 	var leader struct {
 		Leader string `json:"leader"`
 	}
-	if err := r.apiGet(marathonAPILeader, nil, &leader); err != nil {
+	if err := r.apiGet(ctx, marathonAPILeader, nil, &leader); err != nil {
 		return "", err
 	}
+	r.hosts.setLeader(leader.Leader)
 
 	return leader.Leader, nil
 }
 
 // AbdicateLeader abdicates the marathon leadership
 func (r *marathonClient) AbdicateLeader() (string, error) {
+	return r.AbdicateLeaderContext(context.Background())
+}
+
+// AbdicateLeaderContext is like AbdicateLeader but uses the given context
+func (r *marathonClient) AbdicateLeaderContext(ctx context.Context) (string, error) {
 	var message struct {
 		Message string `json:"message"`
 	}
 
-	if err := r.apiDelete(marathonAPILeader, nil, &message); err != nil {
+	if err := r.apiDelete(ctx, marathonAPILeader, nil, &message); err != nil {
 		return "", err
 	}
+	r.hosts.resetLeader()
 
 	return message.Message, nil
 }
@@ -0,0 +1,844 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// informerEvents are the events the informer keeps its cache in sync with
+const informerEvents = EventIDApplications | EventIDAPIRequest | EventIDAddHealthCheck | EventIDRemoveHealthCheck |
+	EventIDGroupChangeSuccess | EventIDDeploymentInfo | EventIDDeploymentSuccess | EventIDDeploymentFailed |
+	EventIDDeploymentStepSuccess | EventIDDeploymentStepFailed | EventIDPods | EventIDInstances | EventIDStreamConnected
+
+// InformerHandler is notified of the changes of the objects in the cache of an informer. The
+// objects are *Application, *Pod, *Group or *Deployment; the handlers left empty are ignored.
+// The handlers are called in order from the goroutine running the informer.
+type InformerHandler struct {
+	// OnAdd is called for an object added to the cache
+	OnAdd func(obj interface{})
+	// OnUpdate is called for an object which changed
+	OnUpdate func(oldObj, newObj interface{})
+	// OnDelete is called for an object removed from the cache
+	OnDelete func(obj interface{})
+}
+
+// IndexFunc returns the values an application is indexed under
+type IndexFunc func(application *Application) []string
+
+// IndexByLabel returns an index of the applications by the value of the label
+func IndexByLabel(key string) IndexFunc {
+	return func(application *Application) []string {
+		if application.Labels == nil {
+			return nil
+		}
+		if value, found := (*application.Labels)[key]; found {
+			return []string{value}
+		}
+		return nil
+	}
+}
+
+// IndexByHost indexes the applications by the hosts their tasks run on
+func IndexByHost(application *Application) []string {
+	var hosts []string
+	seen := make(map[string]bool)
+	for _, task := range application.Tasks {
+		if task.Host != "" && !seen[task.Host] {
+			seen[task.Host] = true
+			hosts = append(hosts, task.Host)
+		}
+	}
+	return hosts
+}
+
+// InformerConfig is the configuration of an informer
+type InformerConfig struct {
+	// ResyncPeriod is the interval of the full resyncs of the cache, defaults to 5 minutes.
+	// A negative period disables them.
+	ResyncPeriod time.Duration
+	// RefreshDelay is the delay the refreshes of the objects the events are about are batched
+	// over, defaults to 1 second. The status updates are applied to the cache right away.
+	RefreshDelay time.Duration
+	// Indexers are the indexes of the applications, by name
+	Indexers map[string]IndexFunc
+	// OnError is called with the errors of the resyncs and refreshes, which are retried later
+	OnError func(err error)
+}
+
+// Informer keeps a local cache of the applications, pods, groups and deployments of Marathon.
+// It lists them all when it starts and then keeps them in sync from the event stream,
+// resyncing them all periodically and after the event stream reconnects.
+type Informer struct {
+	sync.RWMutex
+	client   Marathon
+	config   InformerConfig
+	handlers []InformerHandler
+	synced   bool
+	// the refreshes requested by the events, owned by the goroutine running the informer
+	pending refreshes
+
+	applications map[string]*Application
+	pods         map[string]*Pod
+	groups       map[string]*Group
+	deployments  map[string]*Deployment
+	// the indexes of the applications: index name -> value -> application IDs
+	indices map[string]map[string]map[string]bool
+}
+
+// NewInformer creates an informer of the client, which is started by Run
+func NewInformer(client Marathon, config InformerConfig) *Informer {
+	if config.ResyncPeriod == 0 {
+		config.ResyncPeriod = 5 * time.Minute
+	}
+	if config.RefreshDelay <= 0 {
+		config.RefreshDelay = time.Second
+	}
+	if config.OnError == nil {
+		config.OnError = func(error) {}
+	}
+
+	indices := make(map[string]map[string]map[string]bool, len(config.Indexers))
+	for name := range config.Indexers {
+		indices[name] = make(map[string]map[string]bool)
+	}
+
+	return &Informer{
+		client:       client,
+		config:       config,
+		applications: make(map[string]*Application),
+		pods:         make(map[string]*Pod),
+		groups:       make(map[string]*Group),
+		deployments:  make(map[string]*Deployment),
+		indices:      indices,
+	}
+}
+
+// AddHandler adds a handler notified of the changes of the cache. Handlers must be added
+// before the informer is run.
+func (i *Informer) AddHandler(handler InformerHandler) {
+	i.Lock()
+	defer i.Unlock()
+	i.handlers = append(i.handlers, handler)
+}
+
+// Run lists all objects and then keeps the cache in sync until the context is done. It returns
+// an error if the initial list fails.
+func (i *Informer) Run(ctx context.Context) error {
+	// step: listen to the events first, so no change is missed during the list
+	events, err := i.client.AddEventsListener(informerEvents)
+	if err != nil {
+		return err
+	}
+	defer i.client.RemoveEventsListener(events)
+
+	if err := i.resync(ctx); err != nil {
+		return err
+	}
+	i.Lock()
+	i.synced = true
+	i.Unlock()
+
+	var resync <-chan time.Time
+	if i.config.ResyncPeriod > 0 {
+		ticker := time.NewTicker(i.config.ResyncPeriod)
+		defer ticker.Stop()
+		resync = ticker.C
+	}
+
+	var refresh <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			i.handleEvent(ctx, event)
+			if refresh == nil && !i.pending.empty() {
+				refresh = time.After(i.config.RefreshDelay)
+			}
+		case <-refresh:
+			refresh = nil
+			i.refresh(ctx)
+		case <-resync:
+			if err := i.resync(ctx); err != nil {
+				i.config.OnError(err)
+			}
+		}
+	}
+}
+
+// HasSynced reports whether the initial list is done
+func (i *Informer) HasSynced() bool {
+	i.RLock()
+	defer i.RUnlock()
+	return i.synced
+}
+
+// GetApplication returns the cached application
+func (i *Informer) GetApplication(id string) (*Application, bool) {
+	i.RLock()
+	defer i.RUnlock()
+	application, found := i.applications[id]
+	return application, found
+}
+
+// ListApplications returns the cached applications, ordered by ID
+func (i *Informer) ListApplications() []*Application {
+	i.RLock()
+	defer i.RUnlock()
+	applications := make([]*Application, 0, len(i.applications))
+	for _, id := range sortedIDs(i.applications) {
+		applications = append(applications, i.applications[id])
+	}
+	return applications
+}
+
+// ListApplicationsByIndex returns the cached applications indexed under the value, ordered by ID
+func (i *Informer) ListApplicationsByIndex(indexName, value string) ([]*Application, error) {
+	i.RLock()
+	defer i.RUnlock()
+	index, found := i.indices[indexName]
+	if !found {
+		return nil, fmt.Errorf("index %s does not exist", indexName)
+	}
+	var ids []string
+	for id := range index[value] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	applications := make([]*Application, 0, len(ids))
+	for _, id := range ids {
+		applications = append(applications, i.applications[id])
+	}
+	return applications, nil
+}
+
+// GetPod returns the cached pod
+func (i *Informer) GetPod(id string) (*Pod, bool) {
+	i.RLock()
+	defer i.RUnlock()
+	pod, found := i.pods[id]
+	return pod, found
+}
+
+// ListPods returns the cached pods, ordered by ID
+func (i *Informer) ListPods() []*Pod {
+	i.RLock()
+	defer i.RUnlock()
+	pods := make([]*Pod, 0, len(i.pods))
+	for _, id := range sortedIDs(i.pods) {
+		pods = append(pods, i.pods[id])
+	}
+	return pods
+}
+
+// GetGroup returns the cached group
+func (i *Informer) GetGroup(id string) (*Group, bool) {
+	i.RLock()
+	defer i.RUnlock()
+	group, found := i.groups[id]
+	return group, found
+}
+
+// ListGroups returns the cached groups, nested ones included, ordered by ID
+func (i *Informer) ListGroups() []*Group {
+	i.RLock()
+	defer i.RUnlock()
+	groups := make([]*Group, 0, len(i.groups))
+	for _, id := range sortedIDs(i.groups) {
+		groups = append(groups, i.groups[id])
+	}
+	return groups
+}
+
+// GetDeployment returns the cached deployment
+func (i *Informer) GetDeployment(id string) (*Deployment, bool) {
+	i.RLock()
+	defer i.RUnlock()
+	deployment, found := i.deployments[id]
+	return deployment, found
+}
+
+// ListDeployments returns the cached deployments, ordered by ID
+func (i *Informer) ListDeployments() []*Deployment {
+	i.RLock()
+	defer i.RUnlock()
+	deployments := make([]*Deployment, 0, len(i.deployments))
+	for _, id := range sortedIDs(i.deployments) {
+		deployments = append(deployments, i.deployments[id])
+	}
+	return deployments
+}
+
+// handleEvent applies the status updates to the cache and batches the refreshes of the objects
+// the other events are about
+func (i *Informer) handleEvent(ctx context.Context, event *Event) {
+	switch e := event.Event.(type) {
+	case *EventStatusUpdate:
+		if !i.applyStatusUpdate(e) {
+			i.pending.object(e.AppID)
+		}
+	case *EventHealthCheckChanged:
+		i.pending.object(e.AppID)
+	case *EventFailedHealthCheck:
+		i.pending.object(e.AppID)
+	case *EventAddHealthCheck:
+		i.pending.object(e.AppID)
+	case *EventRemoveHealthCheck:
+		i.pending.object(e.AppID)
+	case *EventAppTerminated:
+		i.pending.object(e.AppID)
+	case *EventInstanceChanged:
+		i.pending.object(e.RunSpecID)
+	case *EventInstanceHealthChanged:
+		i.pending.object(e.RunSpecID)
+	case *EventUnknownInstanceTerminated:
+		i.pending.object(e.RunSpecID)
+	case *EventPodCreated:
+		i.pending.pod(e.URI)
+	case *EventPodUpdated:
+		i.pending.pod(e.URI)
+	case *EventPodDeleted:
+		i.pending.pod(e.URI)
+	case *EventAPIRequest:
+		if e.AppDefinition != nil {
+			i.pending.object(e.AppDefinition.ID)
+		}
+	case *EventStreamConnected:
+		// step: the events of the gap are missed
+		if e.Gap > 0 {
+			if err := i.resync(ctx); err != nil {
+				i.config.OnError(err)
+			}
+		}
+	case *EventGroupChangeSuccess:
+		i.pending.groups = true
+	case *EventDeploymentSuccess, *EventDeploymentFailed:
+		// step: the objects of a finished deployment have reached their final state
+		i.RLock()
+		if deployment, found := i.deployments[deploymentID(event)]; found {
+			for _, id := range append(append([]string{}, deployment.AffectedApps...), deployment.AffectedPods...) {
+				i.pending.object(id)
+			}
+		}
+		i.RUnlock()
+		i.pending.deployments = true
+	default:
+		i.pending.deployments = true
+	}
+}
+
+// refreshes are the refreshes requested by the events, made once the refresh delay has passed so
+// that a burst of events about the same objects makes a single request per object
+type refreshes struct {
+	// the IDs of the applications and pods to fetch
+	objects map[string]bool
+	// whether all the pods, groups and deployments are listed
+	pods        bool
+	groups      bool
+	deployments bool
+}
+
+// object requests the refresh of the application or pod
+func (r *refreshes) object(id string) {
+	if id == "" {
+		return
+	}
+	if r.objects == nil {
+		r.objects = make(map[string]bool)
+	}
+	r.objects[id] = true
+}
+
+// pod requests the refresh of the pod of the URI of a pod event, or all the pods when the
+// URI has no pod ID, as for the creations
+func (r *refreshes) pod(uri string) {
+	if id := podIDFromURI(uri); id != "" {
+		r.object(id)
+		return
+	}
+	r.pods = true
+}
+
+// empty reports whether no refresh is requested
+func (r *refreshes) empty() bool {
+	return len(r.objects) == 0 && !r.pods && !r.groups && !r.deployments
+}
+
+// refresh makes the refreshes requested by the events
+func (i *Informer) refresh(ctx context.Context) {
+	pending := i.pending
+	i.pending = refreshes{}
+
+	var errs []error
+	for _, id := range sortedIDs(pending.objects) {
+		errs = append(errs, i.refreshApplicationOrPod(ctx, id))
+	}
+	if pending.pods {
+		errs = append(errs, i.refreshPods(ctx))
+	}
+	if pending.groups {
+		errs = append(errs, i.refreshGroups(ctx))
+	}
+	if pending.deployments {
+		errs = append(errs, i.refreshDeployments(ctx))
+	}
+	for _, err := range errs {
+		if err != nil {
+			i.config.OnError(err)
+		}
+	}
+}
+
+// applyStatusUpdate applies the status update to the task of the cached application, returning
+// false when the application is not cached and so has to be fetched
+func (i *Informer) applyStatusUpdate(update *EventStatusUpdate) bool {
+	i.RLock()
+	cached, found := i.applications[update.AppID]
+	i.RUnlock()
+	if !found || update.TaskID == "" {
+		return false
+	}
+
+	// step: the cached objects are shared with the handlers, so update a copy
+	application := *cached
+	application.Tasks = make([]*Task, 0, len(cached.Tasks)+1)
+	var task *Task
+	for _, existing := range cached.Tasks {
+		if existing.ID == update.TaskID {
+			copied := *existing
+			task = &copied
+			if terminalTaskStates[update.TaskStatus] {
+				continue
+			}
+			application.Tasks = append(application.Tasks, task)
+			continue
+		}
+		application.Tasks = append(application.Tasks, existing)
+	}
+	if task == nil {
+		if terminalTaskStates[update.TaskStatus] {
+			return true
+		}
+		task = &Task{ID: update.TaskID, AppID: update.AppID, StagedAt: update.Timestamp}
+		application.Tasks = append(application.Tasks, task)
+	}
+	task.State = update.TaskStatus
+	task.Host = update.Host
+	task.SlaveID = update.SlaveID
+	task.Ports = update.Ports
+	task.IPAddresses = update.IPAddresses
+	task.Version = update.Version
+	if update.TaskStatus == "TASK_RUNNING" && task.StartedAt == "" {
+		task.StartedAt = update.Timestamp
+	}
+	countTasks(&application)
+
+	i.set(kindApplication, application.ID, &application)
+
+	return true
+}
+
+// terminalTaskStates are the states of the tasks which are not listed by Marathon anymore
+var terminalTaskStates = map[string]bool{
+	"TASK_FINISHED":         true,
+	"TASK_FAILED":           true,
+	"TASK_KILLED":           true,
+	"TASK_LOST":             true,
+	"TASK_ERROR":            true,
+	"TASK_DROPPED":          true,
+	"TASK_GONE":             true,
+	"TASK_GONE_BY_OPERATOR": true,
+}
+
+// countTasks counts the tasks of the application by state and health again
+func countTasks(application *Application) {
+	application.TasksRunning, application.TasksStaged = 0, 0
+	application.TasksHealthy, application.TasksUnhealthy = 0, 0
+	for _, task := range application.Tasks {
+		switch task.State {
+		case "TASK_RUNNING":
+			application.TasksRunning++
+		case "TASK_STAGING", "TASK_STARTING":
+			application.TasksStaged++
+		}
+		if len(task.HealthCheckResults) == 0 {
+			continue
+		}
+		healthy := true
+		for _, result := range task.HealthCheckResults {
+			if result != nil && !result.Alive {
+				healthy = false
+			}
+		}
+		if healthy {
+			application.TasksHealthy++
+		} else {
+			application.TasksUnhealthy++
+		}
+	}
+}
+
+// deploymentID returns the ID of the deployment a deployment event is about
+func deploymentID(event *Event) string {
+	switch e := event.Event.(type) {
+	case *EventDeploymentSuccess:
+		return e.ID
+	case *EventDeploymentFailed:
+		return e.ID
+	}
+	return ""
+}
+
+// resync lists all objects, replacing the cache
+func (i *Informer) resync(ctx context.Context) error {
+	applications, err := i.client.ApplicationsContext(ctx, url.Values{"embed": []string{"apps.tasks"}})
+	if err != nil {
+		return err
+	}
+	pods, err := i.listPods(ctx)
+	if err != nil {
+		return err
+	}
+	if err := i.refreshGroups(ctx); err != nil {
+		return err
+	}
+	if err := i.refreshDeployments(ctx); err != nil {
+		return err
+	}
+
+	objects := make(map[string]interface{}, len(applications.Apps))
+	for index := range applications.Apps {
+		objects[applications.Apps[index].ID] = &applications.Apps[index]
+	}
+	i.replace(kindApplication, objects)
+
+	objects = make(map[string]interface{}, len(pods))
+	for index := range pods {
+		objects[pods[index].ID] = &pods[index]
+	}
+	i.replace(kindPod, objects)
+
+	return nil
+}
+
+// refreshApplicationOrPod fetches the application or pod with the ID again, removing it once
+// Marathon does not know it anymore
+func (i *Informer) refreshApplicationOrPod(ctx context.Context, id string) error {
+	if id == "" {
+		return nil
+	}
+
+	i.RLock()
+	_, isPod := i.pods[id]
+	i.RUnlock()
+
+	// step: the events do not tell applications and pods apart, so try the pod on a missing application
+	if !isPod {
+		application, err := i.client.ApplicationContext(ctx, id)
+		if err == nil {
+			i.set(kindApplication, id, application)
+			return nil
+		}
+		if !isNotFound(err) {
+			return err
+		}
+		i.delete(kindApplication, id)
+	}
+
+	pod, err := i.client.PodContext(ctx, id)
+	if err == nil {
+		i.set(kindPod, id, pod)
+		return nil
+	}
+	if !isNotFound(err) {
+		return err
+	}
+	i.delete(kindPod, id)
+
+	return nil
+}
+
+// refreshPods lists the pods again
+func (i *Informer) refreshPods(ctx context.Context) error {
+	pods, err := i.listPods(ctx)
+	if err != nil {
+		return err
+	}
+	objects := make(map[string]interface{}, len(pods))
+	for index := range pods {
+		objects[pods[index].ID] = &pods[index]
+	}
+	i.replace(kindPod, objects)
+
+	return nil
+}
+
+// listPods lists the pods, none when Marathon does not support them
+func (i *Informer) listPods(ctx context.Context) ([]Pod, error) {
+	pods, err := i.client.PodsContext(ctx)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+	return pods, nil
+}
+
+// isNotFound reports whether the error is a not found response of the API
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*APIError)
+	return ok && apiErr.ErrCode == ErrCodeNotFound
+}
+
+// refreshGroups lists the groups again
+func (i *Informer) refreshGroups(ctx context.Context) error {
+	root, err := i.client.GroupsContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	objects := make(map[string]interface{})
+	var flatten func(groups []*Group)
+	flatten = func(groups []*Group) {
+		for _, group := range groups {
+			objects[group.ID] = group
+			flatten(group.Groups)
+		}
+	}
+	flatten(root.Groups)
+	i.replace(kindGroup, objects)
+
+	return nil
+}
+
+// refreshDeployments lists the deployments again
+func (i *Informer) refreshDeployments(ctx context.Context) error {
+	deployments, err := i.client.DeploymentsContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	objects := make(map[string]interface{}, len(deployments))
+	for _, deployment := range deployments {
+		objects[deployment.ID] = deployment
+	}
+	i.replace(kindDeployment, objects)
+
+	return nil
+}
+
+// the kinds of objects in the cache
+const (
+	kindApplication = iota
+	kindPod
+	kindGroup
+	kindDeployment
+)
+
+// notification is a change of the cache to notify the handlers of
+type notification struct {
+	oldObj interface{}
+	newObj interface{}
+}
+
+// replace replaces the objects of the kind with the given ones
+func (i *Informer) replace(kind int, objects map[string]interface{}) {
+	i.Lock()
+	var notifications []notification
+	for _, id := range i.idsLocked(kind) {
+		if _, found := objects[id]; !found {
+			obj, _ := i.lookupLocked(kind, id)
+			notifications = append(notifications, i.deleteLocked(kind, id, obj))
+		}
+	}
+	for _, id := range sortedIDs(objects) {
+		if n, changed := i.setLocked(kind, id, objects[id]); changed {
+			notifications = append(notifications, n)
+		}
+	}
+	i.Unlock()
+
+	i.notify(notifications)
+}
+
+// set adds or updates an object of the kind
+func (i *Informer) set(kind int, id string, obj interface{}) {
+	i.Lock()
+	n, changed := i.setLocked(kind, id, obj)
+	i.Unlock()
+
+	if changed {
+		i.notify([]notification{n})
+	}
+}
+
+// delete removes an object of the kind
+func (i *Informer) delete(kind int, id string) {
+	i.Lock()
+	obj, found := i.lookupLocked(kind, id)
+	if !found {
+		i.Unlock()
+		return
+	}
+	n := i.deleteLocked(kind, id, obj)
+	i.Unlock()
+
+	i.notify([]notification{n})
+}
+
+// lookupLocked returns the object of the kind. The caller must hold the lock.
+func (i *Informer) lookupLocked(kind int, id string) (interface{}, bool) {
+	// step: keep the typed nil pointers of the missing objects out of the interface
+	switch kind {
+	case kindApplication:
+		if obj, found := i.applications[id]; found {
+			return obj, true
+		}
+	case kindPod:
+		if obj, found := i.pods[id]; found {
+			return obj, true
+		}
+	case kindGroup:
+		if obj, found := i.groups[id]; found {
+			return obj, true
+		}
+	case kindDeployment:
+		if obj, found := i.deployments[id]; found {
+			return obj, true
+		}
+	}
+	return nil, false
+}
+
+// idsLocked returns the IDs of the objects of the kind. The caller must hold the lock.
+func (i *Informer) idsLocked(kind int) []string {
+	switch kind {
+	case kindApplication:
+		return sortedIDs(i.applications)
+	case kindPod:
+		return sortedIDs(i.pods)
+	case kindGroup:
+		return sortedIDs(i.groups)
+	case kindDeployment:
+		return sortedIDs(i.deployments)
+	}
+	return nil
+}
+
+// setLocked adds or updates an object, returning whether it changed. The caller must hold the lock.
+func (i *Informer) setLocked(kind int, id string, obj interface{}) (notification, bool) {
+	old, found := i.lookupLocked(kind, id)
+	if found && reflect.DeepEqual(old, obj) {
+		return notification{}, false
+	}
+
+	switch kind {
+	case kindApplication:
+		application := obj.(*Application)
+		if found {
+			i.unindex(id, old.(*Application))
+		}
+		i.applications[id] = application
+		i.index(id, application)
+	case kindPod:
+		i.pods[id] = obj.(*Pod)
+	case kindGroup:
+		i.groups[id] = obj.(*Group)
+	case kindDeployment:
+		i.deployments[id] = obj.(*Deployment)
+	}
+
+	return notification{oldObj: old, newObj: obj}, true
+}
+
+// deleteLocked removes an object. The caller must hold the lock.
+func (i *Informer) deleteLocked(kind int, id string, obj interface{}) notification {
+	switch kind {
+	case kindApplication:
+		i.unindex(id, obj.(*Application))
+		delete(i.applications, id)
+	case kindPod:
+		delete(i.pods, id)
+	case kindGroup:
+		delete(i.groups, id)
+	case kindDeployment:
+		delete(i.deployments, id)
+	}
+	return notification{oldObj: obj}
+}
+
+// index adds the application to the indexes. The caller must hold the lock.
+func (i *Informer) index(id string, application *Application) {
+	for name, indexer := range i.config.Indexers {
+		for _, value := range indexer(application) {
+			if i.indices[name][value] == nil {
+				i.indices[name][value] = make(map[string]bool)
+			}
+			i.indices[name][value][id] = true
+		}
+	}
+}
+
+// unindex removes the application from the indexes. The caller must hold the lock.
+func (i *Informer) unindex(id string, application *Application) {
+	for name, indexer := range i.config.Indexers {
+		for _, value := range indexer(application) {
+			delete(i.indices[name][value], id)
+			if len(i.indices[name][value]) == 0 {
+				delete(i.indices[name], value)
+			}
+		}
+	}
+}
+
+// notify calls the handlers for the changes
+func (i *Informer) notify(notifications []notification) {
+	i.RLock()
+	handlers := i.handlers
+	i.RUnlock()
+
+	for _, n := range notifications {
+		for _, handler := range handlers {
+			switch {
+			case n.oldObj == nil && handler.OnAdd != nil:
+				handler.OnAdd(n.newObj)
+			case n.oldObj != nil && n.newObj != nil && handler.OnUpdate != nil:
+				handler.OnUpdate(n.oldObj, n.newObj)
+			case n.newObj == nil && handler.OnDelete != nil:
+				handler.OnDelete(n.oldObj)
+			}
+		}
+	}
+}
+
+// sortedIDs returns the IDs of the objects in order
+func sortedIDs(objects interface{}) []string {
+	var ids []string
+	for _, key := range reflect.ValueOf(objects).MapKeys() {
+		ids = append(ids, key.String())
+	}
+	sort.Strings(ids)
+	return ids
+}
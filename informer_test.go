@@ -0,0 +1,497 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// informerClient is a stub of the client serving the objects the informer lists
+type informerClient struct {
+	Marathon
+
+	sync.Mutex
+	applications map[string]*Application
+	pods         map[string]*Pod
+	groups       *Groups
+	deployments  []*Deployment
+	events       EventsChannel
+	// whether the pods are not supported, as by the Marathon versions before 1.4
+	noPods bool
+	// the number of requests by method
+	requests map[string]int
+}
+
+func newInformerClient() *informerClient {
+	return &informerClient{
+		applications: make(map[string]*Application),
+		pods:         make(map[string]*Pod),
+		groups:       &Groups{},
+		events:       make(EventsChannel),
+		requests:     make(map[string]int),
+	}
+}
+
+func (c *informerClient) requestsOf(method string) int {
+	c.Lock()
+	defer c.Unlock()
+	return c.requests[method]
+}
+
+func (c *informerClient) AddEventsListener(filter int) (EventsChannel, error) {
+	return c.events, nil
+}
+
+func (c *informerClient) RemoveEventsListener(channel EventsChannel) {}
+
+func (c *informerClient) ApplicationsContext(ctx context.Context, v url.Values) (*Applications, error) {
+	c.Lock()
+	defer c.Unlock()
+	applications := &Applications{}
+	for _, application := range c.applications {
+		applications.Apps = append(applications.Apps, *application)
+	}
+	return applications, nil
+}
+
+func (c *informerClient) ApplicationContext(ctx context.Context, id string) (*Application, error) {
+	c.Lock()
+	defer c.Unlock()
+	c.requests["ApplicationContext"]++
+	if application, found := c.applications[id]; found {
+		copied := *application
+		return &copied, nil
+	}
+	return nil, NewAPIError(http.StatusNotFound, []byte(`{"message": "not found"}`))
+}
+
+func (c *informerClient) PodsContext(ctx context.Context) ([]Pod, error) {
+	c.Lock()
+	defer c.Unlock()
+	if c.noPods {
+		return nil, NewAPIError(http.StatusNotFound, []byte(`{"message": "not found"}`))
+	}
+	var pods []Pod
+	for _, pod := range c.pods {
+		pods = append(pods, *pod)
+	}
+	return pods, nil
+}
+
+func (c *informerClient) PodContext(ctx context.Context, id string) (*Pod, error) {
+	c.Lock()
+	defer c.Unlock()
+	if pod, found := c.pods[id]; found {
+		copied := *pod
+		return &copied, nil
+	}
+	return nil, NewAPIError(http.StatusNotFound, []byte(`{"message": "not found"}`))
+}
+
+func (c *informerClient) GroupsContext(ctx context.Context) (*Groups, error) {
+	c.Lock()
+	defer c.Unlock()
+	return c.groups, nil
+}
+
+func (c *informerClient) DeploymentsContext(ctx context.Context) ([]*Deployment, error) {
+	c.Lock()
+	defer c.Unlock()
+	c.requests["DeploymentsContext"]++
+	return c.deployments, nil
+}
+
+func (c *informerClient) setApplication(id, host string, labels map[string]string) {
+	c.Lock()
+	defer c.Unlock()
+	c.applications[id] = &Application{ID: id, Labels: &labels, Tasks: []*Task{{AppID: id, Host: host}}}
+}
+
+// recorder records the notifications of an informer
+type recorder struct {
+	sync.Mutex
+	notifications []string
+}
+
+func (r *recorder) handler() InformerHandler {
+	record := func(kind string, obj interface{}) {
+		r.Lock()
+		defer r.Unlock()
+		var id string
+		switch o := obj.(type) {
+		case *Application:
+			id = o.ID
+		case *Pod:
+			id = o.ID
+		case *Group:
+			id = o.ID
+		case *Deployment:
+			id = o.ID
+		}
+		r.notifications = append(r.notifications, kind+" "+id)
+	}
+	return InformerHandler{
+		OnAdd:    func(obj interface{}) { record("add", obj) },
+		OnUpdate: func(oldObj, newObj interface{}) { record("update", newObj) },
+		OnDelete: func(obj interface{}) { record("delete", obj) },
+	}
+}
+
+func (r *recorder) waitFor(t *testing.T, notifications ...string) {
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		r.Lock()
+		done := len(r.notifications) >= len(notifications)
+		r.Unlock()
+		if done {
+			break
+		}
+	}
+	r.Lock()
+	defer r.Unlock()
+	assert.Equal(t, notifications, r.notifications)
+	r.notifications = nil
+}
+
+func runInformer(t *testing.T, informer *Informer) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- informer.Run(ctx)
+	}()
+	for deadline := time.Now().Add(2 * time.Second); !informer.HasSynced() && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+	require.True(t, informer.HasSynced())
+	return func() {
+		cancel()
+		assert.NoError(t, <-done)
+	}
+}
+
+func TestInformerInitialList(t *testing.T) {
+	client := newInformerClient()
+	client.setApplication("/app", "host1", nil)
+	client.pods["/pod"] = &Pod{ID: "/pod"}
+	client.groups = &Groups{Groups: []*Group{{ID: "/product", Groups: []*Group{{ID: "/product/service"}}}}}
+	client.deployments = []*Deployment{{ID: "deployment"}}
+
+	informer := NewInformer(client, InformerConfig{})
+	events := &recorder{}
+	informer.AddHandler(events.handler())
+	defer runInformer(t, informer)()
+
+	events.waitFor(t, "add /product", "add /product/service", "add deployment", "add /app", "add /pod")
+	application, found := informer.GetApplication("/app")
+	require.True(t, found)
+	assert.Equal(t, "host1", application.Tasks[0].Host)
+	_, found = informer.GetApplication("/missing")
+	assert.False(t, found)
+	assert.Len(t, informer.ListApplications(), 1)
+	assert.Len(t, informer.ListPods(), 1)
+	_, found = informer.GetPod("/pod")
+	assert.True(t, found)
+	groups := informer.ListGroups()
+	require.Len(t, groups, 2)
+	assert.Equal(t, "/product/service", groups[1].ID)
+	_, found = informer.GetDeployment("deployment")
+	assert.True(t, found)
+	assert.Len(t, informer.ListDeployments(), 1)
+}
+
+func TestInformerEvents(t *testing.T) {
+	client := newInformerClient()
+	client.setApplication("/app", "host1", nil)
+	client.pods["/pod"] = &Pod{ID: "/pod"}
+
+	informer := NewInformer(client, InformerConfig{ResyncPeriod: -1, RefreshDelay: 10 * time.Millisecond})
+	events := &recorder{}
+	informer.AddHandler(events.handler())
+	defer runInformer(t, informer)()
+	events.waitFor(t, "add /app", "add /pod")
+
+	// step: an unchanged object is not notified
+	client.events <- &Event{Event: &EventHealthCheckChanged{AppID: "/app"}}
+	time.Sleep(50 * time.Millisecond)
+	events.Lock()
+	assert.Empty(t, events.notifications)
+	events.Unlock()
+	client.setApplication("/app", "host2", nil)
+	client.events <- &Event{Event: &EventHealthCheckChanged{AppID: "/app"}}
+	events.waitFor(t, "update /app")
+	application, _ := informer.GetApplication("/app")
+	assert.Equal(t, "host2", application.Tasks[0].Host)
+
+	client.setApplication("/new", "host1", nil)
+	client.events <- &Event{Event: &EventAPIRequest{AppDefinition: &Application{ID: "/new"}}}
+	events.waitFor(t, "add /new")
+
+	// step: the events of unknown pods are resolved
+	client.Lock()
+	client.pods["/new-pod"] = &Pod{ID: "/new-pod"}
+	client.Unlock()
+	client.events <- &Event{Event: &EventStatusUpdate{AppID: "/new-pod"}}
+	events.waitFor(t, "add /new-pod")
+
+	client.Lock()
+	delete(client.applications, "/app")
+	delete(client.pods, "/pod")
+	client.Unlock()
+	client.events <- &Event{Event: &EventAppTerminated{AppID: "/app"}}
+	client.events <- &Event{Event: &EventStatusUpdate{AppID: "/pod"}}
+	events.waitFor(t, "delete /app", "delete /pod")
+
+	client.Lock()
+	client.groups = &Groups{Groups: []*Group{{ID: "/product"}}}
+	client.Unlock()
+	client.events <- &Event{Event: &EventGroupChangeSuccess{GroupID: "/product"}}
+	events.waitFor(t, "add /product")
+}
+
+func TestInformerPodAndInstanceEvents(t *testing.T) {
+	client := newInformerClient()
+	client.pods["/pod"] = &Pod{ID: "/pod"}
+
+	informer := NewInformer(client, InformerConfig{ResyncPeriod: -1, RefreshDelay: 10 * time.Millisecond})
+	events := &recorder{}
+	informer.AddHandler(events.handler())
+	defer runInformer(t, informer)()
+	events.waitFor(t, "add /pod")
+
+	// step: the creations have no pod ID in their URI
+	client.Lock()
+	client.pods["/new"] = &Pod{ID: "/new"}
+	client.Unlock()
+	client.events <- &Event{Event: &EventPodCreated{URI: "/v2/pods"}}
+	events.waitFor(t, "add /new")
+
+	client.Lock()
+	client.pods["/pod"] = &Pod{ID: "/pod", Labels: map[string]string{"team": "red"}}
+	client.Unlock()
+	client.events <- &Event{Event: &EventPodUpdated{URI: "/v2/pods/pod"}}
+	events.waitFor(t, "update /pod")
+
+	client.Lock()
+	client.pods["/pod"] = &Pod{ID: "/pod", Labels: map[string]string{"team": "blue"}}
+	client.Unlock()
+	client.events <- &Event{Event: &EventInstanceChanged{RunSpecID: "/pod"}}
+	events.waitFor(t, "update /pod")
+
+	client.Lock()
+	delete(client.pods, "/pod")
+	client.Unlock()
+	client.events <- &Event{Event: &EventPodDeleted{URI: "/v2/pods/pod::status"}}
+	events.waitFor(t, "delete /pod")
+}
+
+func TestInformerStatusUpdates(t *testing.T) {
+	client := newInformerClient()
+	client.applications["/app"] = &Application{ID: "/app", TasksRunning: 1,
+		Tasks: []*Task{{ID: "task1", AppID: "/app", Host: "host1", State: "TASK_RUNNING"}}}
+
+	informer := NewInformer(client, InformerConfig{ResyncPeriod: -1, RefreshDelay: 10 * time.Millisecond})
+	events := &recorder{}
+	informer.AddHandler(events.handler())
+	defer runInformer(t, informer)()
+	events.waitFor(t, "add /app")
+	cached, _ := informer.GetApplication("/app")
+
+	// step: the status updates are applied without fetching the application
+	client.events <- &Event{Event: &EventStatusUpdate{AppID: "/app", TaskID: "task2", TaskStatus: "TASK_STAGING",
+		Host: "host2", Timestamp: "2019-01-01T00:00:00.000Z"}}
+	events.waitFor(t, "update /app")
+	client.events <- &Event{Event: &EventStatusUpdate{AppID: "/app", TaskID: "task2", TaskStatus: "TASK_RUNNING",
+		Host: "host2", Ports: []int{31000}, Timestamp: "2019-01-01T00:00:01.000Z"}}
+	events.waitFor(t, "update /app")
+	application, _ := informer.GetApplication("/app")
+	require.Len(t, application.Tasks, 2)
+	assert.Equal(t, &Task{ID: "task2", AppID: "/app", Host: "host2", Ports: []int{31000}, State: "TASK_RUNNING",
+		StagedAt: "2019-01-01T00:00:00.000Z", StartedAt: "2019-01-01T00:00:01.000Z"}, application.Tasks[1])
+	assert.Equal(t, 2, application.TasksRunning)
+
+	client.events <- &Event{Event: &EventStatusUpdate{AppID: "/app", TaskID: "task1", TaskStatus: "TASK_KILLED"}}
+	events.waitFor(t, "update /app")
+	application, _ = informer.GetApplication("/app")
+	require.Len(t, application.Tasks, 1)
+	assert.Equal(t, "task2", application.Tasks[0].ID)
+	assert.Equal(t, 1, application.TasksRunning)
+	assert.Equal(t, 0, client.requestsOf("ApplicationContext"))
+
+	// step: the objects handed to the handlers are not modified
+	assert.Len(t, cached.Tasks, 1)
+	assert.Equal(t, "TASK_RUNNING", cached.Tasks[0].State)
+}
+
+func TestInformerBatchesRefreshes(t *testing.T) {
+	client := newInformerClient()
+	client.setApplication("/app", "host1", nil)
+
+	informer := NewInformer(client, InformerConfig{ResyncPeriod: -1, RefreshDelay: 100 * time.Millisecond})
+	events := &recorder{}
+	informer.AddHandler(events.handler())
+	defer runInformer(t, informer)()
+	events.waitFor(t, "add /app")
+	deployments := client.requestsOf("DeploymentsContext")
+
+	client.setApplication("/app", "host2", nil)
+	client.Lock()
+	client.deployments = []*Deployment{{ID: "deployment"}}
+	client.Unlock()
+	for n := 0; n < 5; n++ {
+		client.events <- &Event{Event: &EventHealthCheckChanged{AppID: "/app"}}
+		client.events <- &Event{Event: &EventDeploymentInfo{}}
+		client.events <- &Event{Event: &EventDeploymentStepSuccess{}}
+	}
+	events.waitFor(t, "update /app", "add deployment")
+	assert.Equal(t, 1, client.requestsOf("ApplicationContext"))
+	assert.Equal(t, deployments+1, client.requestsOf("DeploymentsContext"))
+}
+
+func TestInformerWithoutPods(t *testing.T) {
+	client := newInformerClient()
+	client.noPods = true
+	client.setApplication("/app", "host1", nil)
+
+	informer := NewInformer(client, InformerConfig{
+		ResyncPeriod: -1,
+		RefreshDelay: 10 * time.Millisecond,
+		OnError:      func(err error) { t.Errorf("unexpected error: %s", err) },
+	})
+	events := &recorder{}
+	informer.AddHandler(events.handler())
+	defer runInformer(t, informer)()
+	events.waitFor(t, "add /app")
+	assert.Empty(t, informer.ListPods())
+
+	client.setApplication("/new", "host1", nil)
+	client.events <- &Event{Event: &EventPodCreated{URI: "/v2/pods"}}
+	client.events <- &Event{Event: &EventAPIRequest{AppDefinition: &Application{ID: "/new"}}}
+	events.waitFor(t, "add /new")
+}
+
+func TestInformerDeploymentEvents(t *testing.T) {
+	client := newInformerClient()
+	client.setApplication("/app", "host1", nil)
+
+	informer := NewInformer(client, InformerConfig{ResyncPeriod: -1, RefreshDelay: 10 * time.Millisecond})
+	events := &recorder{}
+	informer.AddHandler(events.handler())
+	defer runInformer(t, informer)()
+	events.waitFor(t, "add /app")
+
+	client.Lock()
+	client.deployments = []*Deployment{{ID: "deployment", AffectedApps: []string{"/app"}}}
+	client.Unlock()
+	client.events <- &Event{Event: &EventDeploymentInfo{}}
+	events.waitFor(t, "add deployment")
+
+	// step: the affected applications are refreshed once the deployment finishes
+	client.Lock()
+	client.deployments = nil
+	client.Unlock()
+	client.setApplication("/app", "host2", nil)
+	client.events <- &Event{Event: &EventDeploymentSuccess{ID: "deployment"}}
+	events.waitFor(t, "update /app", "delete deployment")
+}
+
+func TestInformerResync(t *testing.T) {
+	client := newInformerClient()
+	client.setApplication("/app", "host1", nil)
+
+	informer := NewInformer(client, InformerConfig{ResyncPeriod: 10 * time.Millisecond})
+	events := &recorder{}
+	informer.AddHandler(events.handler())
+	defer runInformer(t, informer)()
+	events.waitFor(t, "add /app")
+
+	client.Lock()
+	delete(client.applications, "/app")
+	client.Unlock()
+	client.setApplication("/other", "host1", nil)
+	events.waitFor(t, "delete /app", "add /other")
+}
+
+func TestInformerResyncsAfterStreamGap(t *testing.T) {
+	client := newInformerClient()
+	client.setApplication("/app", "host1", nil)
+
+	informer := NewInformer(client, InformerConfig{ResyncPeriod: -1, RefreshDelay: 10 * time.Millisecond})
+	events := &recorder{}
+	informer.AddHandler(events.handler())
+	defer runInformer(t, informer)()
+	events.waitFor(t, "add /app")
+
+	// step: the first connection has no gap, the event after it ensures it is handled
+	client.setApplication("/other", "host1", nil)
+	client.events <- &Event{Event: &EventStreamConnected{}}
+	client.events <- &Event{Event: &EventDeploymentInfo{}}
+	events.Lock()
+	assert.Empty(t, events.notifications)
+	events.Unlock()
+
+	client.events <- &Event{Event: &EventStreamConnected{Gap: time.Second}}
+	events.waitFor(t, "add /other")
+}
+
+func TestInformerIndexers(t *testing.T) {
+	client := newInformerClient()
+	client.setApplication("/a", "host1", map[string]string{"team": "red"})
+	client.setApplication("/b", "host2", map[string]string{"team": "red"})
+	client.setApplication("/c", "host1", nil)
+
+	informer := NewInformer(client, InformerConfig{
+		ResyncPeriod: -1,
+		RefreshDelay: 10 * time.Millisecond,
+		Indexers: map[string]IndexFunc{
+			"team": IndexByLabel("team"),
+			"host": IndexByHost,
+		},
+	})
+	events := &recorder{}
+	informer.AddHandler(events.handler())
+	defer runInformer(t, informer)()
+	events.waitFor(t, "add /a", "add /b", "add /c")
+
+	ids := func(applications []*Application, err error) []string {
+		require.NoError(t, err)
+		var ids []string
+		for _, application := range applications {
+			ids = append(ids, application.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"/a", "/b"}, ids(informer.ListApplicationsByIndex("team", "red")))
+	assert.Equal(t, []string{"/a", "/c"}, ids(informer.ListApplicationsByIndex("host", "host1")))
+	assert.Nil(t, ids(informer.ListApplicationsByIndex("host", "host3")))
+	_, err := informer.ListApplicationsByIndex("missing", "value")
+	assert.Error(t, err)
+
+	// step: the indexes follow the updates
+	client.setApplication("/a", "host3", map[string]string{"team": "blue"})
+	client.events <- &Event{Event: &EventHealthCheckChanged{AppID: "/a"}}
+	events.waitFor(t, "update /a")
+	assert.Equal(t, []string{"/b"}, ids(informer.ListApplicationsByIndex("team", "red")))
+	assert.Equal(t, []string{"/a"}, ids(informer.ListApplicationsByIndex("host", "host3")))
+	assert.Equal(t, []string{"/c"}, ids(informer.ListApplicationsByIndex("host", "host1")))
+}
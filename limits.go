@@ -0,0 +1,137 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RequestLimits limits the rate and the concurrency of API requests. The zero value sets no limit.
+type RequestLimits struct {
+	// RequestsPerSecond is the rate requests are sent at on average, zero for no limit
+	RequestsPerSecond float64
+	// Burst is the number of requests which may be sent at once above the rate, defaults to 1
+	Burst int
+	// MaxInFlight is the maximum number of requests waiting for a response, zero for no limit
+	MaxInFlight int
+}
+
+// requestLimiter enforces RequestLimits
+type requestLimiter struct {
+	// the name of the budget in the metrics
+	name    string
+	bucket  *tokenBucket
+	slots   chan struct{}
+	metrics func() Metrics
+}
+
+// newRequestLimiter returns a limiter enforcing the limits, or nil when there are none
+func newRequestLimiter(name string, limits RequestLimits, metrics func() Metrics) *requestLimiter {
+	if limits.RequestsPerSecond <= 0 && limits.MaxInFlight <= 0 {
+		return nil
+	}
+
+	limiter := &requestLimiter{name: name, metrics: metrics}
+	if limits.RequestsPerSecond > 0 {
+		limiter.bucket = newTokenBucket(limits.RequestsPerSecond, limits.Burst)
+	}
+	if limits.MaxInFlight > 0 {
+		limiter.slots = make(chan struct{}, limits.MaxInFlight)
+	}
+
+	return limiter
+}
+
+// acquire waits for the request to be allowed by the limits, or for the context to be done. The
+// returned function must be called once the response has been received.
+func (l *requestLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	start := time.Now()
+	if l.bucket != nil {
+		if err := l.bucket.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	release := func() {}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+			var once sync.Once
+			release = func() {
+				once.Do(func() { <-l.slots })
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	l.metrics().ObserveLimitWait(l.name, time.Since(start))
+
+	return release, nil
+}
+
+// tokenBucket is a token bucket rate limiter
+type tokenBucket struct {
+	sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a full bucket refilled at the rate, holding burst tokens at most
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait takes a token from the bucket, waiting for one to be available or for the context to be done
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	// step: take the token right away, waiting until the debt is paid off
+	b.tokens--
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// step: give the token back for others to use
+		b.Lock()
+		b.tokens++
+		b.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	bucket := newTokenBucket(50, 2)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		require.NoError(t, bucket.wait(context.Background()))
+	}
+	// step: the burst goes through at once, the others are spaced by 20ms
+	assert.True(t, time.Since(start) >= 30*time.Millisecond, "waited %s only", time.Since(start))
+}
+
+func TestTokenBucketContext(t *testing.T) {
+	bucket := newTokenBucket(1, 1)
+	require.NoError(t, bucket.wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, bucket.wait(ctx))
+
+	// step: the token of the cancelled wait was given back
+	bucket.Lock()
+	defer bucket.Unlock()
+	assert.True(t, bucket.tokens > -0.5, "tokens: %f", bucket.tokens)
+}
+
+// newBlockingServer returns a server blocking the reads until unblock is closed, keeping track
+// of the maximum number of reads in flight
+func newBlockingServer(unblock chan struct{}, inFlight, maxInFlight *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			w.Write([]byte(`{"id": "/group"}`))
+			return
+		}
+		current := atomic.AddInt32(inFlight, 1)
+		defer atomic.AddInt32(inFlight, -1)
+		for {
+			max := atomic.LoadInt32(maxInFlight)
+			if current <= max || atomic.CompareAndSwapInt32(maxInFlight, max, current) {
+				break
+			}
+		}
+		<-unblock
+		w.Write([]byte(`{"apps": []}`))
+	}))
+}
+
+func TestMaxInFlight(t *testing.T) {
+	var inFlight, maxInFlight int32
+	unblock := make(chan struct{})
+	server := newBlockingServer(unblock, &inFlight, &maxInFlight)
+	defer server.Close()
+
+	metrics := NewMemoryMetrics()
+	client, err := NewClient(Config{
+		URL:        server.URL,
+		ReadLimits: RequestLimits{MaxInFlight: 2},
+		Metrics:    metrics,
+	})
+	require.NoError(t, err)
+	client.(*marathonClient).hosts.setLeader(server.URL)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Applications(nil)
+			assert.NoError(t, err)
+		}()
+	}
+	for deadline := time.Now().Add(time.Second); atomic.LoadInt32(&inFlight) < 2 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// step: writes have a budget of their own
+	assert.NoError(t, client.CreateGroup(&Group{ID: "/group"}))
+
+	// step: waiting for a slot respects the context
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = client.ApplicationsContext(ctx, nil)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	close(unblock)
+	wg.Wait()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxInFlight))
+
+	metrics.Lock()
+	defer metrics.Unlock()
+	if assert.Contains(t, metrics.limitWaits, "read") {
+		assert.Equal(t, uint64(5), metrics.limitWaits["read"].count)
+	}
+	assert.NotContains(t, metrics.limitWaits, "write")
+}
+
+func TestRequestLimiterDisabled(t *testing.T) {
+	assert.Nil(t, newRequestLimiter("read", RequestLimits{}, nil))
+
+	var limiter *requestLimiter
+	release, err := limiter.acquire(context.Background())
+	require.NoError(t, err)
+	release()
+}
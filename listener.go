@@ -0,0 +1,212 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import "sync/atomic"
+
+// defaultListenerQueueSize is the number of events queued for a listener by default
+const defaultListenerQueueSize = 1024
+
+// OverflowPolicy decides what happens to the events of a listener whose queue is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for the listener to make room, holding up the delivery of the
+	// events to all the listeners
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest drops the oldest event queued for the listener
+	OverflowDropOldest
+	// OverflowDropNewest drops the event which does not fit in the queue
+	OverflowDropNewest
+	// OverflowDisconnect removes the listener, closing its channel
+	OverflowDisconnect
+)
+
+// ListenerOptions are the settings of an events listener
+type ListenerOptions struct {
+	// QueueSize is the number of events queued for the listener, in order, defaults to 1024
+	QueueSize int
+	// Overflow is the policy applied once the queue is full, defaults to OverflowBlock
+	Overflow OverflowPolicy
+	// Predicates are matched by the events delivered to the listener, beyond the event types.
+	// The stream lifecycle events are delivered regardless.
+	Predicates []EventPredicate
+}
+
+// AddEventsListenerWithOptions is like AddEventsListener but with the settings of the queue of the listener
+func (r *marathonClient) AddEventsListenerWithOptions(filter int, options ListenerOptions) (EventsChannel, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.closed {
+		return nil, ErrClientClosed
+	}
+
+	// step: someone has asked to start listening to event, we need to register for events
+	// if we haven't done so already
+	if err := r.registerSubscription(); err != nil {
+		return nil, err
+	}
+
+	channel := r.addEventsListener(filter, options)
+	r.eventStreamFilterChanged()
+	return channel, nil
+}
+
+// DroppedEvents returns the number of events dropped for the listener
+func (r *marathonClient) DroppedEvents(channel EventsChannel) uint64 {
+	r.RLock()
+	defer r.RUnlock()
+	if listener, found := r.listeners[channel]; found {
+		return atomic.LoadUint64(listener.dropped)
+	}
+	return 0
+}
+
+// addEventsListener adds a listener and starts the delivery of its events. The caller must hold the lock.
+func (r *marathonClient) addEventsListener(filter int, options ListenerOptions) EventsChannel {
+	if options.QueueSize <= 0 {
+		options.QueueSize = defaultListenerQueueSize
+	}
+
+	channel := make(EventsChannel)
+	r.lastListenerID++
+	listener := EventsChannelContext{
+		filter:     filter,
+		done:       make(chan struct{}),
+		queue:      make(chan *Event, options.QueueSize),
+		overflow:   options.Overflow,
+		predicates: options.Predicates,
+		dropped:    new(uint64),
+		id:         r.lastListenerID,
+	}
+	r.listeners[channel] = listener
+
+	r.background.Add(1)
+	go r.deliverEvents(channel, listener)
+
+	return channel
+}
+
+// deliverEvents delivers the events queued for the listener in order. Once the listener is
+// removed, it drops the events left and closes the channel.
+func (r *marathonClient) deliverEvents(channel EventsChannel, listener EventsChannelContext) {
+	defer r.background.Done()
+	metrics := r.client.metrics()
+
+	defer func() {
+		for {
+			select {
+			case event := <-listener.queue:
+				listener.drop(event, metrics)
+			default:
+				metrics.ListenerRemoved(listener.id)
+				close(channel)
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case event := <-listener.queue:
+			select {
+			case channel <- event:
+				metrics.ListenerQueueDepth(listener.id, len(listener.queue))
+			case <-listener.done:
+				listener.drop(event, metrics)
+				return
+			}
+		case <-listener.done:
+			return
+		}
+	}
+}
+
+// wants reports whether the listener wants the event
+func (l EventsChannelContext) wants(event *Event) bool {
+	if event.ID&l.filter == 0 {
+		return false
+	}
+	if event.ID&EventIDStreamLifecycle != 0 {
+		return true
+	}
+	for _, predicate := range l.predicates {
+		if !predicate(event) {
+			return false
+		}
+	}
+	return true
+}
+
+// enqueue queues the event for delivery, applying the overflow policy when the queue is full. It
+// returns false when the listener has to be disconnected.
+func (l EventsChannelContext) enqueue(event *Event, metrics Metrics) bool {
+	defer func() {
+		metrics.ListenerQueueDepth(l.id, len(l.queue))
+	}()
+
+	switch l.overflow {
+	case OverflowDropOldest:
+		for {
+			select {
+			case l.queue <- event:
+				return true
+			case <-l.done:
+				l.drop(event, metrics)
+				return true
+			default:
+			}
+			// note: the oldest event may be delivered meanwhile, leaving room as well
+			select {
+			case oldest := <-l.queue:
+				l.drop(oldest, metrics)
+			default:
+			}
+		}
+	case OverflowDropNewest:
+		select {
+		case l.queue <- event:
+		case <-l.done:
+			l.drop(event, metrics)
+		default:
+			l.drop(event, metrics)
+		}
+	case OverflowDisconnect:
+		select {
+		case l.queue <- event:
+		case <-l.done:
+			l.drop(event, metrics)
+		default:
+			l.drop(event, metrics)
+			return false
+		}
+	default:
+		select {
+		case l.queue <- event:
+		case <-l.done:
+			l.drop(event, metrics)
+		}
+	}
+	return true
+}
+
+// drop counts an event dropped for the listener
+func (l EventsChannelContext) drop(event *Event, metrics Metrics) {
+	atomic.AddUint64(l.dropped, 1)
+	metrics.EventDropped(event.Name)
+}
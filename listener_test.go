@@ -0,0 +1,149 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestListenerEvent(sequence int) *Event {
+	return &Event{ID: EventIDDeploymentInfo, Name: "deployment_info", Event: sequence}
+}
+
+// readListenerEvents reads the sequences of the events until the channel is closed or idle
+func readListenerEvents(channel EventsChannel) []int {
+	var sequences []int
+	for {
+		select {
+		case event, more := <-channel:
+			if !more {
+				return sequences
+			}
+			sequences = append(sequences, event.Event.(int))
+		case <-time.After(100 * time.Millisecond):
+			return sequences
+		}
+	}
+}
+
+func TestListenerDeliversEventsInOrder(t *testing.T) {
+	endpoint := newFakeMarathonEndpoint(t, nil)
+	defer endpoint.Close()
+	client := endpoint.Client.(*marathonClient)
+
+	client.Lock()
+	channel := client.addEventsListener(EventIDDeploymentInfo, ListenerOptions{})
+	client.Unlock()
+
+	var expected []int
+	for i := 0; i < 100; i++ {
+		client.dispatchEvent(newTestListenerEvent(i))
+		expected = append(expected, i)
+	}
+	assert.Equal(t, expected, readListenerEvents(channel))
+	assert.Equal(t, uint64(0), client.DroppedEvents(channel))
+}
+
+func TestListenerOverflowPolicies(t *testing.T) {
+	cases := []struct {
+		overflow     OverflowPolicy
+		received     []int
+		dropped      uint64
+		disconnected bool
+	}{
+		{overflow: OverflowDropNewest, received: []int{0, 1, 2}, dropped: 2},
+		{overflow: OverflowDropOldest, received: []int{0, 3, 4}, dropped: 2},
+		{overflow: OverflowDisconnect, dropped: 4, disconnected: true},
+	}
+	for _, c := range cases {
+		endpoint := newFakeMarathonEndpoint(t, nil)
+		client := endpoint.Client.(*marathonClient)
+
+		client.Lock()
+		channel := client.addEventsListener(EventIDDeploymentInfo, ListenerOptions{QueueSize: 2, Overflow: c.overflow})
+		listener := client.listeners[channel]
+		client.Unlock()
+
+		// step: wait for the first event to be taken off the queue, nobody reads the channel yet
+		client.dispatchEvent(newTestListenerEvent(0))
+		for deadline := time.Now().Add(time.Second); len(listener.queue) > 0 && time.Now().Before(deadline); {
+			time.Sleep(time.Millisecond)
+		}
+		for i := 1; i < 5; i++ {
+			client.dispatchEvent(newTestListenerEvent(i))
+		}
+
+		assert.Equal(t, c.received, readListenerEvents(channel), "policy %d", c.overflow)
+		assert.Equal(t, c.dropped, atomic.LoadUint64(listener.dropped), "policy %d", c.overflow)
+		client.RLock()
+		_, found := client.listeners[channel]
+		client.RUnlock()
+		assert.Equal(t, c.disconnected, !found, "policy %d", c.overflow)
+
+		endpoint.Close()
+	}
+}
+
+func TestListenerOverflowBlock(t *testing.T) {
+	endpoint := newFakeMarathonEndpoint(t, nil)
+	defer endpoint.Close()
+	client := endpoint.Client.(*marathonClient)
+
+	client.Lock()
+	channel := client.addEventsListener(EventIDDeploymentInfo, ListenerOptions{QueueSize: 1})
+	client.Unlock()
+
+	dispatched := make(chan struct{})
+	go func() {
+		defer close(dispatched)
+		for i := 0; i < 5; i++ {
+			client.dispatchEvent(newTestListenerEvent(i))
+		}
+	}()
+	select {
+	case <-dispatched:
+		require.Fail(t, "the dispatch did not block on the full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, readListenerEvents(channel))
+	<-dispatched
+	assert.Equal(t, uint64(0), client.DroppedEvents(channel))
+
+	// step: a blocked dispatch is released when the listener is removed
+	dispatched = make(chan struct{})
+	go func() {
+		defer close(dispatched)
+		for i := 0; i < 5; i++ {
+			client.dispatchEvent(newTestListenerEvent(i))
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	client.RemoveEventsListener(channel)
+	select {
+	case <-dispatched:
+	case <-time.After(time.Second):
+		assert.Fail(t, "the dispatch is still blocked")
+	}
+	for range channel {
+	}
+}
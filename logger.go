@@ -0,0 +1,136 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// LogLevel is the severity of a log message
+type LogLevel int
+
+const (
+	// LogLevelDebug is for detailed messages about every request and event
+	LogLevelDebug LogLevel = iota
+	// LogLevelInfo is for noteworthy events of the client
+	LogLevelInfo
+	// LogLevelWarn is for failures the client recovers from
+	LogLevelWarn
+	// LogLevelError is for failures the client can not recover from
+	LogLevelError
+)
+
+// String returns the name of the level
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "debug"
+	case LogLevelInfo:
+		return "info"
+	case LogLevelWarn:
+		return "warn"
+	case LogLevelError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// Logger logs leveled messages with structured fields, given as alternating keys and values,
+// e.g. logger.Warn("request failed", "member", member, "error", err)
+type Logger interface {
+	Debug(msg string, fields ...interface{})
+	Info(msg string, fields ...interface{})
+	Warn(msg string, fields ...interface{})
+	Error(msg string, fields ...interface{})
+}
+
+// nopLogger discards every message
+type nopLogger struct{}
+
+// NewNopLogger returns a logger which discards every message
+func NewNopLogger() Logger {
+	return nopLogger{}
+}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// stdLogger writes the messages of a minimum level to a standard logger, formatted as logfmt
+type stdLogger struct {
+	logger *log.Logger
+	level  LogLevel
+}
+
+// NewStdLogger returns a logger writing the messages of the given level and above to the
+// standard logger, formatted as key=value pairs
+func NewStdLogger(logger *log.Logger, level LogLevel) Logger {
+	return &stdLogger{logger: logger, level: level}
+}
+
+// Debug implements Logger
+func (l *stdLogger) Debug(msg string, fields ...interface{}) {
+	l.log(LogLevelDebug, msg, fields)
+}
+
+// Info implements Logger
+func (l *stdLogger) Info(msg string, fields ...interface{}) {
+	l.log(LogLevelInfo, msg, fields)
+}
+
+// Warn implements Logger
+func (l *stdLogger) Warn(msg string, fields ...interface{}) {
+	l.log(LogLevelWarn, msg, fields)
+}
+
+// Error implements Logger
+func (l *stdLogger) Error(msg string, fields ...interface{}) {
+	l.log(LogLevelError, msg, fields)
+}
+
+// log formats and writes the message when its level is enabled
+func (l *stdLogger) log(level LogLevel, msg string, fields []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	var line bytes.Buffer
+	line.WriteString("level=" + level.String())
+	line.WriteString(" msg=" + logfmtValue(msg))
+	for i := 0; i < len(fields); i += 2 {
+		key := fmt.Sprint(fields[i])
+		value := "(MISSING)"
+		if i+1 < len(fields) {
+			value = logfmtValue(fmt.Sprint(fields[i+1]))
+		}
+		line.WriteString(" " + key + "=" + value)
+	}
+	l.logger.Println(line.String())
+}
+
+// logfmtValue quotes a value when it can not be written as is
+func logfmtValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " =\"\\\t\r\n") {
+		return strconv.Quote(value)
+	}
+	return value
+}
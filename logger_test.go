@@ -0,0 +1,60 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStdLogger(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	logger := NewStdLogger(log.New(buf, "", 0), LogLevelInfo)
+
+	logger.Debug("not logged")
+	logger.Info("request done", "member", "http://127.0.0.1:8080", "status", 200)
+	logger.Warn("request failed", "path", "v2/apps", "error", errors.New("connection refused"))
+	logger.Error("odd fields", "event_type", "", "dangling")
+
+	assert.Equal(t, `level=info msg="request done" member=http://127.0.0.1:8080 status=200
+level=warn msg="request failed" path=v2/apps error="connection refused"
+level=error msg="odd fields" event_type="" dangling=(MISSING)
+`, buf.String())
+}
+
+func TestLogLevelString(t *testing.T) {
+	assert.Equal(t, "debug", LogLevelDebug.String())
+	assert.Equal(t, "error", LogLevelError.String())
+	assert.Equal(t, "unknown", LogLevel(42).String())
+}
+
+func TestDefaultLogger(t *testing.T) {
+	client, err := NewClient(NewDefaultConfig())
+	assert.NoError(t, err)
+	assert.Equal(t, NewNopLogger(), client.(*marathonClient).logger)
+
+	logger := NewNopLogger()
+	config := NewDefaultConfig()
+	config.Logger = logger
+	client, err = NewClient(config)
+	assert.NoError(t, err)
+	assert.Equal(t, logger, client.(*marathonClient).hosts.logger)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Member describes an available member of the Marathon cluster
+type Member struct {
+	// Endpoint is the URL of the member
+	Endpoint string
+	// Latency is the moving average of the round trip times observed on the
+	// member, zero when none has been observed yet
+	Latency time.Duration
+}
+
+// MemberSelector picks the member of the cluster a request is sent to
+type MemberSelector interface {
+	// Select returns the index of the chosen member. The members are all
+	// available and listed in the order of the configured URL.
+	Select(members []Member) int
+}
+
+// MemberSelectorFunc is an adapter to use a function as a MemberSelector
+type MemberSelectorFunc func(members []Member) int
+
+// Select implements MemberSelector
+func (f MemberSelectorFunc) Select(members []Member) int {
+	return f(members)
+}
+
+// NewFirstMemberSelector returns a selector which always picks the first
+// available member, the others only serving as fail overs
+func NewFirstMemberSelector() MemberSelector {
+	return MemberSelectorFunc(func([]Member) int {
+		return 0
+	})
+}
+
+// roundRobinSelector spreads requests evenly across the available members
+type roundRobinSelector struct {
+	next uint64
+}
+
+// NewRoundRobinSelector returns a selector which picks the available members in turn
+func NewRoundRobinSelector() MemberSelector {
+	return &roundRobinSelector{}
+}
+
+// Select implements MemberSelector
+func (s *roundRobinSelector) Select(members []Member) int {
+	return int((atomic.AddUint64(&s.next, 1) - 1) % uint64(len(members)))
+}
+
+// randomSelector picks a random available member
+type randomSelector struct {
+	sync.Mutex
+	random *rand.Rand
+}
+
+// NewRandomSelector returns a selector which picks a random available member
+func NewRandomSelector() MemberSelector {
+	return &randomSelector{random: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// Select implements MemberSelector
+func (s *randomSelector) Select(members []Member) int {
+	s.Lock()
+	defer s.Unlock()
+	return s.random.Intn(len(members))
+}
+
+// NewLowestLatencySelector returns a selector which picks the available member
+// with the lowest observed latency. Members without any observed latency are
+// picked first so their latency gets known.
+func NewLowestLatencySelector() MemberSelector {
+	return MemberSelectorFunc(func(members []Member) int {
+		best := 0
+		for i, m := range members {
+			if m.Latency < members[best].Latency {
+				best = i
+			}
+		}
+		return best
+	})
+}
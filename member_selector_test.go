@@ -0,0 +1,103 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const selectorTestURL = "http://127.0.0.1:8080,127.0.0.2:8080,127.0.0.3:8080"
+
+func newSelectorCluster(t *testing.T, selector MemberSelector) *cluster {
+	config := Config{HTTPClient: defaultHTTPClient, MemberSelector: selector}
+	c, err := newCluster(&httpClient{config: config}, selectorTestURL, false)
+	require.NoError(t, err)
+	return c
+}
+
+func TestFirstMemberSelector(t *testing.T) {
+	c := newSelectorCluster(t, nil)
+	for i := 0; i < 3; i++ {
+		member, err := c.getMember()
+		require.NoError(t, err)
+		assert.Equal(t, "http://127.0.0.1:8080", member)
+	}
+}
+
+func TestRoundRobinSelector(t *testing.T) {
+	c := newSelectorCluster(t, NewRoundRobinSelector())
+
+	var members []string
+	for i := 0; i < 4; i++ {
+		member, err := c.getMember()
+		require.NoError(t, err)
+		members = append(members, member)
+	}
+	assert.Equal(t, []string{
+		"http://127.0.0.1:8080",
+		"http://127.0.0.2:8080",
+		"http://127.0.0.3:8080",
+		"http://127.0.0.1:8080",
+	}, members)
+}
+
+func TestRandomSelector(t *testing.T) {
+	c := newSelectorCluster(t, NewRandomSelector())
+
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		member, err := c.getMember()
+		require.NoError(t, err)
+		seen[member] = true
+	}
+	assert.Len(t, seen, 3)
+}
+
+func TestLowestLatencySelector(t *testing.T) {
+	c := newSelectorCluster(t, NewLowestLatencySelector())
+	c.healthCheckInterval = time.Hour
+
+	// step: members without observations are tried first
+	c.observeLatency("http://127.0.0.1:8080", 30*time.Millisecond)
+	member, err := c.getMember()
+	require.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.2:8080", member)
+
+	c.observeLatency("http://127.0.0.2:8080", 20*time.Millisecond)
+	c.observeLatency("http://127.0.0.3:8080", 10*time.Millisecond)
+	member, err = c.getMember()
+	require.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.3:8080", member)
+
+	// step: members which are down are never selected
+	c.markDown("http://127.0.0.3:8080")
+	member, err = c.getMember()
+	require.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.2:8080", member)
+}
+
+func TestObserveLatency(t *testing.T) {
+	c := newSelectorCluster(t, nil)
+	c.observeLatency("http://127.0.0.1:8080", 100*time.Millisecond)
+	assert.Equal(t, 100*time.Millisecond, c.members[0].latency)
+	c.observeLatency("http://127.0.0.1:8080", 200*time.Millisecond)
+	assert.Equal(t, 130*time.Millisecond, c.members[0].latency)
+}
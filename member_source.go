@@ -0,0 +1,84 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MemberSource returns the endpoints of the current members of the Marathon cluster, in the
+// same form as the endpoints of Config.URL
+type MemberSource func(ctx context.Context) ([]string, error)
+
+// NewStaticMemberSource returns a source which always returns the given endpoints
+func NewStaticMemberSource(endpoints ...string) MemberSource {
+	return func(context.Context) ([]string, error) {
+		return endpoints, nil
+	}
+}
+
+// NewDNSSRVMemberSource returns a source which looks up the members through the DNS SRV
+// records of the given service, protocol and name, e.g. "marathon", "tcp" and
+// "marathon.mesos". The endpoints use the given scheme, http when empty.
+func NewDNSSRVMemberSource(service, proto, name, scheme string) MemberSource {
+	if scheme == "" {
+		scheme = "http"
+	}
+	return func(ctx context.Context) ([]string, error) {
+		_, records, err := net.DefaultResolver.LookupSRV(ctx, service, proto, name)
+		if err != nil {
+			return nil, err
+		}
+
+		var endpoints []string
+		for _, record := range records {
+			host := net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port)))
+			endpoints = append(endpoints, fmt.Sprintf("%s://%s", scheme, host))
+		}
+		sort.Strings(endpoints)
+
+		return endpoints, nil
+	}
+}
+
+// NewDNSMemberSource returns a source which looks up the members through the DNS A and AAAA
+// records of the given host, all of them listening on the given port. The endpoints use the
+// given scheme, http when empty.
+func NewDNSMemberSource(host string, port int, scheme string) MemberSource {
+	if scheme == "" {
+		scheme = "http"
+	}
+	return func(ctx context.Context) ([]string, error) {
+		addresses, err := net.DefaultResolver.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var endpoints []string
+		for _, address := range addresses {
+			endpoints = append(endpoints, fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(address, strconv.Itoa(port))))
+		}
+		sort.Strings(endpoints)
+
+		return endpoints, nil
+	}
+}
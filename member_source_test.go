@@ -0,0 +1,98 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateMembers(t *testing.T) {
+	cluster, err := newStandardCluster("http://127.0.0.1:3000,127.0.0.2:3000,127.0.0.3:3000")
+	require.NoError(t, err)
+	cluster.healthCheckInterval = time.Hour
+	defer cluster.close()
+
+	cluster.markDown("http://127.0.0.2:3000")
+	cluster.setLeader("127.0.0.3:3000")
+	removed := cluster.members[2]
+
+	err = cluster.updateMembers([]string{"http://127.0.0.1:3000", "http://127.0.0.2:3000", "http://127.0.0.4:3000"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, cluster.size())
+	assert.Equal(t, []string{"http://127.0.0.1:3000", "http://127.0.0.4:3000"}, cluster.activeMembers())
+	assert.Equal(t, []string{"http://127.0.0.2:3000"}, cluster.nonActiveMembers())
+	assert.True(t, removed.removed)
+	assert.Empty(t, cluster.leader)
+
+	// step: invalid endpoints leave the members untouched
+	assert.Error(t, cluster.updateMembers([]string{"http://"}))
+	assert.Equal(t, 3, cluster.size())
+}
+
+func TestDiscoverMembers(t *testing.T) {
+	var lock sync.Mutex
+	endpoints := []string{"http://127.0.0.1:3000"}
+	var fail bool
+	source := func(context.Context) ([]string, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if fail {
+			return nil, errors.New("lookup failed")
+		}
+		return endpoints, nil
+	}
+
+	client, err := NewClient(Config{MemberSource: source, MemberSourceInterval: 10 * time.Millisecond})
+	require.NoError(t, err)
+	defer client.Close(context.Background())
+	hosts := client.(*marathonClient).hosts
+	assert.Equal(t, []string{"http://127.0.0.1:3000"}, hosts.activeMembers())
+
+	lock.Lock()
+	endpoints = []string{"http://127.0.0.1:3000", "http://127.0.0.2:3000"}
+	lock.Unlock()
+	for deadline := time.Now().Add(time.Second); hosts.size() != 2 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Equal(t, []string{"http://127.0.0.1:3000", "http://127.0.0.2:3000"}, hosts.activeMembers())
+
+	// step: failed lookups keep the current members
+	lock.Lock()
+	fail = true
+	lock.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 2, hosts.size())
+}
+
+func TestDNSMemberSource(t *testing.T) {
+	endpoints, err := NewDNSMemberSource("localhost", 8080, "")(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, endpoints, "http://127.0.0.1:8080")
+}
+
+func TestStaticMemberSource(t *testing.T) {
+	endpoints, err := NewStaticMemberSource("http://127.0.0.1:8080")(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://127.0.0.1:8080"}, endpoints)
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics records how the client behaves. The methods are called synchronously
+// from the client, so must be safe for concurrent use and return quickly.
+type Metrics interface {
+	// ObserveRequest records a request sent to a member of the cluster. The path is the API
+	// endpoint without any resource ID or query string, and the status code is zero when no
+	// response was received.
+	ObserveRequest(method, path, member string, statusCode int, latency time.Duration)
+	// MemberDown records a member of the cluster being marked as down
+	MemberDown(member string)
+	// EventStreamReconnect records a reconnection of the SSE event stream
+	EventStreamReconnect()
+	// EventReceived records an event received from Marathon
+	EventReceived(eventType string)
+	// EventDropped records an event which could not be decoded or delivered to a listener
+	EventDropped(eventType string)
+	// ListenerQueueDepth records the number of events pending delivery to an events listener
+	ListenerQueueDepth(listener int, depth int)
+	// ListenerRemoved records the removal of an events listener
+	ListenerRemoved(listener int)
+	// ObserveLimitWait records the time an API request waited for the read or write limits
+	ObserveLimitWait(limit string, wait time.Duration)
+}
+
+// noopMetrics discards everything, it is used when no metrics are configured
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveRequest(string, string, string, int, time.Duration) {}
+func (noopMetrics) MemberDown(string)                                         {}
+func (noopMetrics) EventStreamReconnect()                                     {}
+func (noopMetrics) EventReceived(string)                                      {}
+func (noopMetrics) EventDropped(string)                                       {}
+func (noopMetrics) ListenerQueueDepth(int, int)                               {}
+func (noopMetrics) ListenerRemoved(int)                                       {}
+func (noopMetrics) ObserveLimitWait(string, time.Duration)                    {}
+
+// defaultLatencyBuckets are the upper bounds of the latency histogram buckets
+var defaultLatencyBuckets = []time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// requestKey identifies the requests counted together
+type requestKey struct {
+	method     string
+	path       string
+	member     string
+	statusCode int
+}
+
+// latencyKey identifies the requests whose latencies are observed together
+type latencyKey struct {
+	method string
+	path   string
+	member string
+}
+
+// latencyHistogram is a cumulative histogram of request latencies
+type latencyHistogram struct {
+	// the number of observations less than or equal to each bucket
+	buckets []uint64
+	count   uint64
+	sum     time.Duration
+}
+
+// MemoryMetrics keeps the metrics in memory, from where they can be exported in
+// the Prometheus text format through WritePrometheus or ServeHTTP
+type MemoryMetrics struct {
+	sync.Mutex
+	buckets        []time.Duration
+	requests       map[requestKey]uint64
+	latencies      map[latencyKey]*latencyHistogram
+	membersDown    map[string]uint64
+	reconnects     uint64
+	eventsReceived map[string]uint64
+	eventsDropped  map[string]uint64
+	queueDepths    map[int]int
+	limitWaits     map[string]*latencyHistogram
+}
+
+// NewMemoryMetrics creates an empty MemoryMetrics using the default latency buckets
+func NewMemoryMetrics() *MemoryMetrics {
+	return &MemoryMetrics{
+		buckets:        defaultLatencyBuckets,
+		requests:       make(map[requestKey]uint64),
+		latencies:      make(map[latencyKey]*latencyHistogram),
+		membersDown:    make(map[string]uint64),
+		eventsReceived: make(map[string]uint64),
+		eventsDropped:  make(map[string]uint64),
+		queueDepths:    make(map[int]int),
+		limitWaits:     make(map[string]*latencyHistogram),
+	}
+}
+
+// ObserveRequest implements Metrics
+func (m *MemoryMetrics) ObserveRequest(method, path, member string, statusCode int, latency time.Duration) {
+	m.Lock()
+	defer m.Unlock()
+	m.requests[requestKey{method, path, member, statusCode}]++
+
+	key := latencyKey{method, path, member}
+	histogram, found := m.latencies[key]
+	if !found {
+		histogram = &latencyHistogram{buckets: make([]uint64, len(m.buckets))}
+		m.latencies[key] = histogram
+	}
+	histogram.observe(m.buckets, latency)
+}
+
+// observe adds the duration to the histogram
+func (h *latencyHistogram) observe(buckets []time.Duration, duration time.Duration) {
+	for i, bound := range buckets {
+		if duration <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += duration
+}
+
+// MemberDown implements Metrics
+func (m *MemoryMetrics) MemberDown(member string) {
+	m.Lock()
+	defer m.Unlock()
+	m.membersDown[member]++
+}
+
+// EventStreamReconnect implements Metrics
+func (m *MemoryMetrics) EventStreamReconnect() {
+	m.Lock()
+	defer m.Unlock()
+	m.reconnects++
+}
+
+// EventReceived implements Metrics
+func (m *MemoryMetrics) EventReceived(eventType string) {
+	m.Lock()
+	defer m.Unlock()
+	m.eventsReceived[eventType]++
+}
+
+// EventDropped implements Metrics
+func (m *MemoryMetrics) EventDropped(eventType string) {
+	m.Lock()
+	defer m.Unlock()
+	m.eventsDropped[eventType]++
+}
+
+// ListenerQueueDepth implements Metrics
+func (m *MemoryMetrics) ListenerQueueDepth(listener int, depth int) {
+	m.Lock()
+	defer m.Unlock()
+	m.queueDepths[listener] = depth
+}
+
+// ListenerRemoved implements Metrics
+func (m *MemoryMetrics) ListenerRemoved(listener int) {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.queueDepths, listener)
+}
+
+// ObserveLimitWait implements Metrics
+func (m *MemoryMetrics) ObserveLimitWait(limit string, wait time.Duration) {
+	m.Lock()
+	defer m.Unlock()
+	histogram, found := m.limitWaits[limit]
+	if !found {
+		histogram = &latencyHistogram{buckets: make([]uint64, len(m.buckets))}
+		m.limitWaits[limit] = histogram
+	}
+	histogram.observe(m.buckets, wait)
+}
+
+// metricsAPIPaths are the API endpoints requests are accounted to
+var metricsAPIPaths = []string{
+	marathonAPIEventStream,
+	marathonAPISubscription,
+	marathonAPIApps,
+	marathonAPIPods,
+	marathonAPITasks,
+	marathonAPIDeployments,
+	marathonAPIGroups,
+	marathonAPIQueue,
+	marathonAPIInfo,
+	marathonAPILeader,
+	marathonAPIPing,
+}
+
+// metricsPath returns the API endpoint of a request path, so resource IDs do not end up in the metrics
+func metricsPath(path string) string {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	path = strings.TrimPrefix(path, "/")
+	for _, endpoint := range metricsAPIPaths {
+		if path == endpoint || strings.HasPrefix(path, endpoint+"/") {
+			return endpoint
+		}
+	}
+	return "other"
+}
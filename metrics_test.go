@@ -0,0 +1,139 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsPath(t *testing.T) {
+	cases := map[string]string{
+		marathonAPIApps:                          marathonAPIApps,
+		marathonAPIApps + "/my/app?embed=tasks":  marathonAPIApps,
+		"/" + marathonAPITasks + "/delete":       marathonAPITasks,
+		marathonAPISubscription + "?callbackUrl": marathonAPISubscription,
+		marathonAPIPing:                          marathonAPIPing,
+		"v2/appsfoo":                             "other",
+		"v3/whatever":                            "other",
+	}
+	for path, expected := range cases {
+		assert.Equal(t, expected, metricsPath(path), path)
+	}
+}
+
+func TestMemoryMetricsPrometheus(t *testing.T) {
+	metrics := NewMemoryMetrics()
+	metrics.ObserveRequest("GET", marathonAPIApps, "http://127.0.0.1:8080", 200, 20*time.Millisecond)
+	metrics.ObserveRequest("GET", marathonAPIApps, "http://127.0.0.1:8080", 200, 200*time.Millisecond)
+	metrics.ObserveRequest("GET", marathonAPIApps, "http://127.0.0.1:8080", 0, time.Second)
+	metrics.MemberDown("http://127.0.0.1:8080")
+	metrics.EventStreamReconnect()
+	metrics.EventReceived("status_update_event")
+	metrics.EventDropped("status_update_event")
+	metrics.ListenerQueueDepth(1, 3)
+	metrics.ListenerQueueDepth(2, 1)
+	metrics.ListenerRemoved(2)
+	metrics.ObserveLimitWait("read", 50*time.Millisecond)
+
+	var out bytes.Buffer
+	require.NoError(t, metrics.WritePrometheus(&out))
+	text := out.String()
+
+	labels := `method="GET",path="v2/apps",member="http://127.0.0.1:8080"`
+	for _, line := range []string{
+		"# TYPE marathon_client_requests_total counter",
+		`marathon_client_requests_total{` + labels + `,code="200"} 2`,
+		`marathon_client_requests_total{` + labels + `,code="error"} 1`,
+		"# TYPE marathon_client_request_duration_seconds histogram",
+		`marathon_client_request_duration_seconds_bucket{` + labels + `,le="0.025"} 1`,
+		`marathon_client_request_duration_seconds_bucket{` + labels + `,le="0.25"} 2`,
+		`marathon_client_request_duration_seconds_bucket{` + labels + `,le="1"} 3`,
+		`marathon_client_request_duration_seconds_bucket{` + labels + `,le="+Inf"} 3`,
+		`marathon_client_request_duration_seconds_sum{` + labels + `} 1.22`,
+		`marathon_client_request_duration_seconds_count{` + labels + `} 3`,
+		`marathon_client_member_down_total{member="http://127.0.0.1:8080"} 1`,
+		"marathon_client_event_stream_reconnects_total 1",
+		`marathon_client_events_received_total{event_type="status_update_event"} 1`,
+		`marathon_client_events_dropped_total{event_type="status_update_event"} 1`,
+		`marathon_client_listener_queue_depth{listener="1"} 3`,
+		`marathon_client_limit_wait_seconds_bucket{limit="read",le="0.05"} 1`,
+		`marathon_client_limit_wait_seconds_count{limit="read"} 1`,
+	} {
+		assert.Contains(t, text, line+"\n")
+	}
+	assert.NotContains(t, text, `listener="2"`)
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, prometheusContentType, recorder.Header().Get("Content-Type"))
+	assert.Equal(t, text, recorder.Body.String())
+}
+
+func TestClientMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"apps": []}`))
+	}))
+	defer server.Close()
+
+	metrics := NewMemoryMetrics()
+	client, err := NewClient(Config{URL: server.URL + ",127.0.0.1:0", Metrics: metrics})
+	require.NoError(t, err)
+	marathon := client.(*marathonClient)
+	marathon.hosts.healthCheckInterval = time.Hour
+
+	_, err = client.Applications(nil)
+	require.NoError(t, err)
+	marathon.hosts.markDown("http://127.0.0.1:0")
+	marathon.hosts.markDown("http://127.0.0.1:0")
+
+	// step: register a listener by hand so no subscription is made
+	marathon.Lock()
+	listener := marathon.listeners[marathon.addEventsListener(EventIDDeploymentInfo, ListenerOptions{})]
+	marathon.Unlock()
+	require.NoError(t, marathon.handleEvent(`{"eventType": "deployment_info"}`))
+	assert.Error(t, marathon.handleEvent(`{"eventType": "status_update_event", "ports": "none"}`))
+
+	// step: nobody reads the channel, wait for the first event to be taken off the queue
+	for deadline := time.Now().Add(time.Second); len(listener.queue) > 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	require.NoError(t, marathon.handleEvent(`{"eventType": "deployment_info"}`))
+
+	metrics.Lock()
+	assert.Equal(t, uint64(1), metrics.requests[requestKey{"GET", marathonAPIApps, server.URL, 200}])
+	assert.Equal(t, uint64(1), metrics.membersDown["http://127.0.0.1:0"])
+	assert.Equal(t, uint64(2), metrics.eventsReceived["deployment_info"])
+	assert.Equal(t, uint64(1), metrics.eventsDropped["status_update_event"])
+	assert.Equal(t, 1, metrics.queueDepths[1])
+	metrics.Unlock()
+
+	// step: the pending events are dropped once the listener is gone
+	require.NoError(t, client.Close(context.Background()))
+	metrics.Lock()
+	defer metrics.Unlock()
+	assert.Equal(t, uint64(2), metrics.eventsDropped["deployment_info"])
+	_, found := metrics.queueDepths[1]
+	assert.False(t, found)
+}
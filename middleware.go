@@ -0,0 +1,87 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"net/http"
+	"time"
+)
+
+// Call describes an HTTP call made to a member of the Marathon cluster
+type Call struct {
+	// Request is the HTTP request, which a middleware may modify or replace before
+	// handing the call on. Headers should be set rather than added, as the event
+	// stream request is sent again on every reconnection.
+	Request *http.Request
+	// Method is the HTTP method of the request
+	Method string
+	// Path is the API path of the request, including any query string
+	Path string
+	// Member is the cluster member the request is sent to
+	Member string
+	// Body is the content of the request body, nil when there is none
+	Body []byte
+	// Latency is the round trip time of the request, set once the call has been handed on
+	Latency time.Duration
+}
+
+// CallHandler performs a call and returns the response of the member
+type CallHandler func(call *Call) (*http.Response, error)
+
+// Middleware wraps the handling of every call made to the Marathon cluster: the API
+// requests, the event stream connections and the health checks of the members. It may
+// act on the call before handing it on to next, and on the response after.
+type Middleware func(next CallHandler) CallHandler
+
+// chainMiddlewares wraps the handler in the middlewares, the first one being the outermost
+func chainMiddlewares(middlewares []Middleware, handler CallHandler) CallHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// handleCall sends the call through the configured middlewares, do performing the request
+func (rc *httpClient) handleCall(call *Call, do func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	return chainMiddlewares(rc.config.Middlewares, func(call *Call) (*http.Response, error) {
+		start := time.Now()
+		response, err := rc.doAuthorized(call, do)
+		call.Latency = time.Since(start)
+
+		statusCode := 0
+		if err == nil {
+			statusCode = response.StatusCode
+		}
+		rc.metrics().ObserveRequest(call.Method, metricsPath(call.Path), call.Member, statusCode, call.Latency)
+
+		return response, err
+	})(call)
+}
+
+// middlewareTransport sends the requests of an HTTP client through the configured middlewares
+type middlewareTransport struct {
+	client *httpClient
+	base   http.RoundTripper
+	member string
+	path   string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *middlewareTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	call := &Call{Request: request, Method: request.Method, Path: t.path, Member: t.member}
+	return t.client.handleCall(call, t.base.RoundTrip)
+}
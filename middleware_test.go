@@ -0,0 +1,143 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// callRecorder is a middleware keeping track of the calls it has seen
+type callRecorder struct {
+	sync.Mutex
+	calls    []Call
+	statuses []int
+}
+
+func (c *callRecorder) middleware(next CallHandler) CallHandler {
+	return func(call *Call) (*http.Response, error) {
+		response, err := next(call)
+		c.Lock()
+		defer c.Unlock()
+		c.calls = append(c.calls, *call)
+		if err == nil {
+			c.statuses = append(c.statuses, response.StatusCode)
+		}
+		return response, err
+	}
+}
+
+func (c *callRecorder) paths() []string {
+	c.Lock()
+	defer c.Unlock()
+	var paths []string
+	for _, call := range c.calls {
+		paths = append(paths, call.Path)
+	}
+	return paths
+}
+
+func TestMiddlewares(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Trace-Id") != "trace" {
+			http.Error(w, `{"message": "missing trace"}`, http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var order []string
+	tracing := func(next CallHandler) CallHandler {
+		return func(call *Call) (*http.Response, error) {
+			order = append(order, "tracing")
+			call.Request.Header.Set("X-Trace-Id", "trace")
+			return next(call)
+		}
+	}
+	recorder := &callRecorder{}
+	recording := func(next CallHandler) CallHandler {
+		return func(call *Call) (*http.Response, error) {
+			order = append(order, "recording")
+			return recorder.middleware(next)(call)
+		}
+	}
+
+	client, err := NewClient(Config{URL: server.URL, Middlewares: []Middleware{tracing, recording}})
+	require.NoError(t, err)
+	client.(*marathonClient).hosts.setLeader(server.URL)
+
+	err = client.CreateGroup(&Group{ID: "/group"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"tracing", "recording"}, order)
+	require.Len(t, recorder.calls, 1)
+	call := recorder.calls[0]
+	assert.Equal(t, "POST", call.Method)
+	assert.Equal(t, marathonAPIGroups, call.Path)
+	assert.Equal(t, server.URL, call.Member)
+	assert.Contains(t, string(call.Body), `"id":"/group"`)
+	assert.True(t, call.Latency > 0)
+	assert.Equal(t, []int{http.StatusCreated}, recorder.statuses)
+}
+
+func TestMiddlewaresHealthCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pong"))
+	}))
+	defer server.Close()
+
+	recorder := &callRecorder{}
+	config := Config{HTTPClient: defaultHTTPClient, Middlewares: []Middleware{recorder.middleware}}
+	cluster, err := newCluster(&httpClient{config: config}, server.URL, false)
+	require.NoError(t, err)
+	cluster.healthCheckInterval = 10 * time.Millisecond
+	defer cluster.close()
+
+	cluster.markDown(server.URL)
+	for deadline := time.Now().Add(time.Second); len(cluster.activeMembers()) == 0 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+	require.Len(t, cluster.activeMembers(), 1)
+	assert.Equal(t, []string{"ping"}, recorder.paths())
+}
+
+func TestMiddlewaresEventStream(t *testing.T) {
+	recorder := &callRecorder{}
+	clientCfg := NewDefaultConfig()
+	clientCfg.EventsTransport = EventsTransportSSE
+	clientCfg.Middlewares = []Middleware{recorder.middleware}
+	config := configContainer{client: &clientCfg}
+	endpoint := newFakeMarathonEndpoint(t, &config)
+	defer endpoint.Close()
+
+	_, err := endpoint.Client.AddEventsListener(EventIDApplications)
+	require.NoError(t, err)
+	path := endpoint.Client.(*marathonClient).eventStreamPath()
+	time.Sleep(SSEConnectWaitTime)
+	require.NoError(t, endpoint.Client.Close(context.Background()))
+
+	assert.Contains(t, recorder.paths(), path)
+}
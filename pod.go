@@ -17,6 +17,7 @@ limitations under the License.
 package marathon
 
 import (
+	"context"
 	"fmt"
 )
 
@@ -180,7 +181,12 @@ func (p *Pod) SetExecutorResources(resources *ExecutorResources) *Pod {
 // SupportsPods determines if this version of marathon supports pods
 // If HEAD returns 200 it does
 func (r *marathonClient) SupportsPods() (bool, error) {
-	if err := r.apiHead(marathonAPIPods, nil); err != nil {
+	return r.SupportsPodsContext(context.Background())
+}
+
+// SupportsPodsContext is like SupportsPods but uses the given context
+func (r *marathonClient) SupportsPodsContext(ctx context.Context) (bool, error) {
+	if err := r.apiHead(ctx, marathonAPIPods, nil); err != nil {
 		// If we get a 404 we can return a strict false, otherwise it could be
 		// a valid error
 		if apiErr, ok := err.(*APIError); ok && apiErr.ErrCode == ErrCodeNotFound {
@@ -194,9 +200,14 @@ func (r *marathonClient) SupportsPods() (bool, error) {
 
 // Pod gets a pod object from marathon by name
 func (r *marathonClient) Pod(name string) (*Pod, error) {
+	return r.PodContext(context.Background(), name)
+}
+
+// PodContext is like Pod but uses the given context
+func (r *marathonClient) PodContext(ctx context.Context, name string) (*Pod, error) {
 	uri := buildPodURI(name)
 	result := new(Pod)
-	if err := r.apiGet(uri, nil, result); err != nil {
+	if err := r.apiGet(ctx, uri, nil, result); err != nil {
 		return nil, err
 	}
 
@@ -205,8 +216,13 @@ func (r *marathonClient) Pod(name string) (*Pod, error) {
 
 // Pods gets all pods from marathon
 func (r *marathonClient) Pods() ([]Pod, error) {
+	return r.PodsContext(context.Background())
+}
+
+// PodsContext is like Pods but uses the given context
+func (r *marathonClient) PodsContext(ctx context.Context) ([]Pod, error) {
 	var result []Pod
-	if err := r.apiGet(marathonAPIPods, nil, &result); err != nil {
+	if err := r.apiGet(ctx, marathonAPIPods, nil, &result); err != nil {
 		return nil, err
 	}
 
@@ -215,8 +231,13 @@ func (r *marathonClient) Pods() ([]Pod, error) {
 
 // CreatePod creates a new pod in Marathon
 func (r *marathonClient) CreatePod(pod *Pod) (*Pod, error) {
+	return r.CreatePodContext(context.Background(), pod)
+}
+
+// CreatePodContext is like CreatePod but uses the given context
+func (r *marathonClient) CreatePodContext(ctx context.Context, pod *Pod) (*Pod, error) {
 	result := new(Pod)
-	if err := r.ApiPost(marathonAPIPods, &pod, result); err != nil {
+	if err := r.apiPost(ctx, marathonAPIPods, &pod, result); err != nil {
 		return nil, err
 	}
 
@@ -225,10 +246,15 @@ func (r *marathonClient) CreatePod(pod *Pod) (*Pod, error) {
 
 // DeletePod deletes a pod from marathon
 func (r *marathonClient) DeletePod(name string, force bool) (*DeploymentID, error) {
+	return r.DeletePodContext(context.Background(), name, force)
+}
+
+// DeletePodContext is like DeletePod but uses the given context
+func (r *marathonClient) DeletePodContext(ctx context.Context, name string, force bool) (*DeploymentID, error) {
 	uri := fmt.Sprintf("%s?force=%v", buildPodURI(name), force)
 
 	deployID := new(DeploymentID)
-	if err := r.apiDelete(uri, nil, deployID); err != nil {
+	if err := r.apiDelete(ctx, uri, nil, deployID); err != nil {
 		return nil, err
 	}
 
@@ -237,10 +263,15 @@ func (r *marathonClient) DeletePod(name string, force bool) (*DeploymentID, erro
 
 // UpdatePod creates a new pod in Marathon
 func (r *marathonClient) UpdatePod(pod *Pod, force bool) (*Pod, error) {
+	return r.UpdatePodContext(context.Background(), pod, force)
+}
+
+// UpdatePodContext is like UpdatePod but uses the given context
+func (r *marathonClient) UpdatePodContext(ctx context.Context, pod *Pod, force bool) (*Pod, error) {
 	uri := fmt.Sprintf("%s?force=%v", buildPodURI(pod.ID), force)
 	result := new(Pod)
 
-	if err := r.apiPut(uri, pod, result); err != nil {
+	if err := r.apiPut(ctx, uri, pod, result); err != nil {
 		return nil, err
 	}
 
@@ -249,9 +280,14 @@ func (r *marathonClient) UpdatePod(pod *Pod, force bool) (*Pod, error) {
 
 // PodVersions gets all the deployed versions of a pod
 func (r *marathonClient) PodVersions(name string) ([]string, error) {
+	return r.PodVersionsContext(context.Background(), name)
+}
+
+// PodVersionsContext is like PodVersions but uses the given context
+func (r *marathonClient) PodVersionsContext(ctx context.Context, name string) ([]string, error) {
 	uri := buildPodVersionURI(name)
 	var result []string
-	if err := r.apiGet(uri, nil, &result); err != nil {
+	if err := r.apiGet(ctx, uri, nil, &result); err != nil {
 		return nil, err
 	}
 
@@ -260,9 +296,14 @@ func (r *marathonClient) PodVersions(name string) ([]string, error) {
 
 // PodByVersion gets a pod by a version identifier
 func (r *marathonClient) PodByVersion(name, version string) (*Pod, error) {
+	return r.PodByVersionContext(context.Background(), name, version)
+}
+
+// PodByVersionContext is like PodByVersion but uses the given context
+func (r *marathonClient) PodByVersionContext(ctx context.Context, name, version string) (*Pod, error) {
 	uri := fmt.Sprintf("%s/%s", buildPodVersionURI(name), version)
 	result := new(Pod)
-	if err := r.apiGet(uri, nil, result); err != nil {
+	if err := r.apiGet(ctx, uri, nil, result); err != nil {
 		return nil, err
 	}
 
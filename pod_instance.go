@@ -17,6 +17,7 @@ limitations under the License.
 package marathon
 
 import (
+	"context"
 	"fmt"
 	"time"
 )
@@ -80,9 +81,14 @@ type PodNetworkInfo struct {
 
 // DeletePodInstances deletes all instances of the named pod
 func (r *marathonClient) DeletePodInstances(name string, instances []string) ([]*PodInstance, error) {
+	return r.DeletePodInstancesContext(context.Background(), name, instances)
+}
+
+// DeletePodInstancesContext is like DeletePodInstances but uses the given context
+func (r *marathonClient) DeletePodInstancesContext(ctx context.Context, name string, instances []string) ([]*PodInstance, error) {
 	uri := buildPodInstancesURI(name)
 	var result []*PodInstance
-	if err := r.apiDelete(uri, instances, &result); err != nil {
+	if err := r.apiDelete(ctx, uri, instances, &result); err != nil {
 		return nil, err
 	}
 
@@ -91,9 +97,14 @@ func (r *marathonClient) DeletePodInstances(name string, instances []string) ([]
 
 // DeletePodInstance deletes a specific instance of a pod
 func (r *marathonClient) DeletePodInstance(name, instance string) (*PodInstance, error) {
+	return r.DeletePodInstanceContext(context.Background(), name, instance)
+}
+
+// DeletePodInstanceContext is like DeletePodInstance but uses the given context
+func (r *marathonClient) DeletePodInstanceContext(ctx context.Context, name, instance string) (*PodInstance, error) {
 	uri := fmt.Sprintf("%s/%s", buildPodInstancesURI(name), instance)
 	result := new(PodInstance)
-	if err := r.apiDelete(uri, nil, result); err != nil {
+	if err := r.apiDelete(ctx, uri, nil, result); err != nil {
 		return nil, err
 	}
 
@@ -17,6 +17,7 @@ limitations under the License.
 package marathon
 
 import (
+	"context"
 	"fmt"
 	"time"
 )
@@ -66,9 +67,14 @@ type ContainerTerminationHistory struct {
 
 // PodStatus retrieves the pod configuration from marathon
 func (r *marathonClient) PodStatus(name string) (*PodStatus, error) {
+	return r.PodStatusContext(context.Background(), name)
+}
+
+// PodStatusContext is like PodStatus but uses the given context
+func (r *marathonClient) PodStatusContext(ctx context.Context, name string) (*PodStatus, error) {
 	var podStatus PodStatus
 
-	if err := r.apiGet(buildPodStatusURI(name), nil, &podStatus); err != nil {
+	if err := r.apiGet(ctx, buildPodStatusURI(name), nil, &podStatus); err != nil {
 		return nil, err
 	}
 
@@ -77,9 +83,14 @@ func (r *marathonClient) PodStatus(name string) (*PodStatus, error) {
 
 // PodStatuses retrieves all pod configuration from marathon
 func (r *marathonClient) PodStatuses() ([]*PodStatus, error) {
+	return r.PodStatusesContext(context.Background())
+}
+
+// PodStatusesContext is like PodStatuses but uses the given context
+func (r *marathonClient) PodStatusesContext(ctx context.Context) ([]*PodStatus, error) {
 	var podStatuses []*PodStatus
 
-	if err := r.apiGet(buildPodStatusURI(""), nil, &podStatuses); err != nil {
+	if err := r.apiGet(ctx, buildPodStatusURI(""), nil, &podStatuses); err != nil {
 		return nil, err
 	}
 
@@ -88,12 +99,25 @@ func (r *marathonClient) PodStatuses() ([]*PodStatus, error) {
 
 // WaitOnPod blocks until a pod to be deployed
 func (r *marathonClient) WaitOnPod(name string, timeout time.Duration) error {
-	return r.wait(name, timeout, r.PodIsRunning)
+	return r.WaitOnPodContext(context.Background(), name, timeout)
+}
+
+// WaitOnPodContext is like WaitOnPod but uses the given context
+func (r *marathonClient) WaitOnPodContext(ctx context.Context, name string, timeout time.Duration) error {
+	waiter := newEventWaiter(EventIDInstanceChanged, matchEventAppID(name))
+	return r.wait(ctx, timeout, waiter, func(ctx context.Context) (bool, error) {
+		return r.PodIsRunningContext(ctx, name), nil
+	})
 }
 
 // PodIsRunning returns whether the pod is stably running
 func (r *marathonClient) PodIsRunning(name string) bool {
-	podStatus, err := r.PodStatus(name)
+	return r.PodIsRunningContext(context.Background(), name)
+}
+
+// PodIsRunningContext is like PodIsRunning but uses the given context
+func (r *marathonClient) PodIsRunningContext(ctx context.Context, name string) bool {
+	podStatus, err := r.PodStatusContext(ctx, name)
 	if apiErr, ok := err.(*APIError); ok && apiErr.ErrCode == ErrCodeNotFound {
 		return false
 	}
@@ -0,0 +1,162 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// prometheusContentType is the content type of the Prometheus text format
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// WritePrometheus writes the metrics in the Prometheus text exposition format
+func (m *MemoryMetrics) WritePrometheus(writer io.Writer) error {
+	m.Lock()
+	defer m.Unlock()
+
+	w := bufio.NewWriter(writer)
+
+	// step: the request counts
+	writePrometheusHeader(w, "marathon_client_requests_total", "counter", "Requests sent to the Marathon members.")
+	requests := make(map[string]uint64, len(m.requests))
+	for key, count := range m.requests {
+		requests[requestLabels(key)] = count
+	}
+	for _, labels := range sortedKeys(requests) {
+		fmt.Fprintf(w, "marathon_client_requests_total{%s} %d\n", labels, requests[labels])
+	}
+
+	// step: the request latencies
+	writePrometheusHeader(w, "marathon_client_request_duration_seconds", "histogram", "Latency of the requests sent to the Marathon members.")
+	latencies := make(map[string]*latencyHistogram, len(m.latencies))
+	for key, histogram := range m.latencies {
+		latencies[latencyLabels(key)] = histogram
+	}
+	writePrometheusHistograms(w, "marathon_client_request_duration_seconds", m.buckets, latencies)
+
+	// step: the members marked as down
+	writePrometheusHeader(w, "marathon_client_member_down_total", "counter", "Times a Marathon member was marked as down.")
+	writePrometheusCounters(w, "marathon_client_member_down_total", "member", m.membersDown)
+
+	// step: the event stream reconnections
+	writePrometheusHeader(w, "marathon_client_event_stream_reconnects_total", "counter", "Reconnections of the SSE event stream.")
+	fmt.Fprintf(w, "marathon_client_event_stream_reconnects_total %d\n", m.reconnects)
+
+	// step: the events
+	writePrometheusHeader(w, "marathon_client_events_received_total", "counter", "Events received from Marathon.")
+	writePrometheusCounters(w, "marathon_client_events_received_total", "event_type", m.eventsReceived)
+	writePrometheusHeader(w, "marathon_client_events_dropped_total", "counter", "Events which could not be decoded or delivered to a listener.")
+	writePrometheusCounters(w, "marathon_client_events_dropped_total", "event_type", m.eventsDropped)
+
+	// step: the listener queues
+	writePrometheusHeader(w, "marathon_client_listener_queue_depth", "gauge", "Events pending delivery to an events listener.")
+	var listeners []int
+	for listener := range m.queueDepths {
+		listeners = append(listeners, listener)
+	}
+	sort.Ints(listeners)
+	for _, listener := range listeners {
+		fmt.Fprintf(w, "marathon_client_listener_queue_depth{listener=\"%d\"} %d\n", listener, m.queueDepths[listener])
+	}
+
+	// step: the time waited for the request limits
+	writePrometheusHeader(w, "marathon_client_limit_wait_seconds", "histogram", "Time API requests waited for the read or write limits.")
+	limitWaits := make(map[string]*latencyHistogram, len(m.limitWaits))
+	for limit, histogram := range m.limitWaits {
+		limitWaits[fmt.Sprintf("limit=\"%s\"", escapePrometheusLabel(limit))] = histogram
+	}
+	writePrometheusHistograms(w, "marathon_client_limit_wait_seconds", m.buckets, limitWaits)
+
+	return w.Flush()
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format
+func (m *MemoryMetrics) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", prometheusContentType)
+	m.WritePrometheus(writer)
+}
+
+// writePrometheusHeader writes the help and type lines of a metric
+func writePrometheusHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// writePrometheusCounters writes the counters of a metric with a single label
+func writePrometheusCounters(w io.Writer, name, label string, counters map[string]uint64) {
+	for _, value := range sortedKeys(counters) {
+		fmt.Fprintf(w, "%s{%s=\"%s\"} %d\n", name, label, escapePrometheusLabel(value), counters[value])
+	}
+}
+
+// sortedKeys returns the keys of the counters in order
+func sortedKeys(counters map[string]uint64) []string {
+	var keys []string
+	for key := range counters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writePrometheusHistograms writes the histograms of a metric, keyed by their labels
+func writePrometheusHistograms(w io.Writer, name string, buckets []time.Duration, histograms map[string]*latencyHistogram) {
+	var labelsList []string
+	for labels := range histograms {
+		labelsList = append(labelsList, labels)
+	}
+	sort.Strings(labelsList)
+	for _, labels := range labelsList {
+		histogram := histograms[labels]
+		for i, bound := range buckets {
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n",
+				name, labels, strconv.FormatFloat(bound.Seconds(), 'g', -1, 64), histogram.buckets[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, histogram.count)
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(histogram.sum.Seconds(), 'g', -1, 64))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, histogram.count)
+	}
+}
+
+// requestLabels returns the labels of a request count
+func requestLabels(key requestKey) string {
+	code := "error"
+	if key.statusCode != 0 {
+		code = strconv.Itoa(key.statusCode)
+	}
+	return fmt.Sprintf("%s,code=\"%s\"", latencyLabels(latencyKey{key.method, key.path, key.member}), code)
+}
+
+// latencyLabels returns the labels of a latency histogram
+func latencyLabels(key latencyKey) string {
+	return fmt.Sprintf("method=\"%s\",path=\"%s\",member=\"%s\"",
+		escapePrometheusLabel(key.method), escapePrometheusLabel(key.path), escapePrometheusLabel(key.member))
+}
+
+// prometheusLabelEscaper escapes label values as required by the text format
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapePrometheusLabel escapes a label value
+func escapePrometheusLabel(value string) string {
+	return prometheusLabelEscaper.Replace(value)
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package marathon
 
 import (
+	"context"
 	"fmt"
 )
 
@@ -69,8 +70,13 @@ type UnusedOffer struct {
 
 // Queue retrieves content of the marathon launch queue
 func (r *marathonClient) Queue() (*Queue, error) {
+	return r.QueueContext(context.Background())
+}
+
+// QueueContext is like Queue but uses the given context
+func (r *marathonClient) QueueContext(ctx context.Context) (*Queue, error) {
 	var queue *Queue
-	err := r.apiGet(marathonAPIQueue, nil, &queue)
+	err := r.apiGet(ctx, marathonAPIQueue, nil, &queue)
 	if err != nil {
 		return nil, err
 	}
@@ -80,6 +86,11 @@ func (r *marathonClient) Queue() (*Queue, error) {
 // DeleteQueueDelay resets task launch delay of the specific application
 //		appID:		the ID of the application
 func (r *marathonClient) DeleteQueueDelay(appID string) error {
+	return r.DeleteQueueDelayContext(context.Background(), appID)
+}
+
+// DeleteQueueDelayContext is like DeleteQueueDelay but uses the given context
+func (r *marathonClient) DeleteQueueDelayContext(ctx context.Context, appID string) error {
 	path := fmt.Sprintf("%s/%s/delay", marathonAPIQueue, trimRootPath(appID))
-	return r.apiDelete(path, nil, nil)
+	return r.apiDelete(ctx, path, nil, nil)
 }
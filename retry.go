@@ -0,0 +1,181 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy decides whether a failed API request is sent again, and when
+type RetryPolicy interface {
+	// RetryDelay is consulted after every failed attempt of a request, attempt
+	// being 1 after the first one. It returns whether the request should be
+	// attempted again and how long to wait before doing so.
+	RetryDelay(attempt int, failure *RequestFailure) (time.Duration, bool)
+}
+
+// RequestFailure describes a failed attempt of an API request
+type RequestFailure struct {
+	// Method is the HTTP method of the request
+	Method string
+	// Path is the API path of the request, including any query string
+	Path string
+	// Member is the cluster member the request was sent to
+	Member string
+	// Err is the transport error, set when no response was received
+	Err error
+	// StatusCode is the HTTP status of the response, zero when Err is set
+	StatusCode int
+	// RetryAfter is the delay the server asked for through a Retry-After header
+	RetryAfter time.Duration
+	// LeaderNotElected is set when Marathon rejected the request because no
+	// leader is currently elected. Such a request has not been processed.
+	LeaderNotElected bool
+}
+
+// NotSent reports whether the failed request is known not to have reached
+// Marathon, which makes it safe to send again whatever its method.
+func (f *RequestFailure) NotSent() bool {
+	if f.LeaderNotElected {
+		return true
+	}
+	if urlErr, ok := f.Err.(*url.Error); ok {
+		if opErr, ok := urlErr.Err.(*net.OpError); ok {
+			return opErr.Op == "dial"
+		}
+	}
+	return false
+}
+
+// ExponentialRetryPolicy retries failed requests with an exponentially growing,
+// jittered delay. Requests which are not idempotent are only retried when they
+// are known not to have been processed by Marathon.
+type ExponentialRetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts of a request, including the first one
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts, including delays asked for by Retry-After
+	MaxBackoff time.Duration
+	// Multiplier is the factor the delay grows by after every attempt
+	Multiplier float64
+	// Jitter is the fraction (between 0 and 1) of every delay which is randomized
+	Jitter float64
+	// Idempotent reports whether a request may safely be sent more than once,
+	// IsIdempotent is used when left empty
+	Idempotent func(method, path string) bool
+}
+
+// NewExponentialRetryPolicy creates an ExponentialRetryPolicy with sensible defaults
+func NewExponentialRetryPolicy() *ExponentialRetryPolicy {
+	return &ExponentialRetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// RetryDelay implements RetryPolicy
+func (p *ExponentialRetryPolicy) RetryDelay(attempt int, failure *RequestFailure) (time.Duration, bool) {
+	if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+		return 0, false
+	}
+
+	idempotent := p.Idempotent
+	if idempotent == nil {
+		idempotent = IsIdempotent
+	}
+	retryable := failure.NotSent() || failure.StatusCode == http.StatusTooManyRequests
+	if !retryable && !idempotent(failure.Method, failure.Path) {
+		return 0, false
+	}
+
+	delay := p.backoff(attempt)
+	if failure.RetryAfter > delay {
+		delay = failure.RetryAfter
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	return delay, true
+}
+
+// backoff returns the jittered delay to wait after the given attempt
+func (p *ExponentialRetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		delay = delay * (1 - jitter + jitter*2*rand.Float64())
+	}
+
+	return time.Duration(delay)
+}
+
+// IsIdempotent reports whether sending the given API request more than once has
+// the same effect as sending it once. Creating subscriptions is idempotent in
+// Marathon, every other POST is not.
+func IsIdempotent(method, path string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	case "POST":
+		return strings.HasPrefix(path, marathonAPISubscription)
+	}
+	return false
+}
+
+// isLeaderNotElected checks whether a response is Marathon's refusal to serve a
+// request while no leader is elected
+func isLeaderNotElected(statusCode int, body []byte) bool {
+	return statusCode == http.StatusServiceUnavailable &&
+		bytes.Contains(bytes.ToLower(body), []byte("leader"))
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a
+// number of seconds or an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(time.Now()); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
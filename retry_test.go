@@ -0,0 +1,181 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExponentialRetryPolicy(t *testing.T) {
+	policy := &ExponentialRetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}
+
+	tests := []struct {
+		name      string
+		attempt   int
+		failure   RequestFailure
+		wantDelay time.Duration
+		wantRetry bool
+	}{
+		{
+			name:      "idempotent request",
+			attempt:   1,
+			failure:   RequestFailure{Method: "GET", StatusCode: 500},
+			wantDelay: 100 * time.Millisecond,
+			wantRetry: true,
+		},
+		{
+			name:      "backoff grows",
+			attempt:   2,
+			failure:   RequestFailure{Method: "PUT", StatusCode: 502},
+			wantDelay: 200 * time.Millisecond,
+			wantRetry: true,
+		},
+		{
+			name:      "max attempts reached",
+			attempt:   3,
+			failure:   RequestFailure{Method: "GET", StatusCode: 500},
+			wantRetry: false,
+		},
+		{
+			name:      "non idempotent request",
+			attempt:   1,
+			failure:   RequestFailure{Method: "POST", Path: marathonAPIApps, StatusCode: 500},
+			wantRetry: false,
+		},
+		{
+			name:      "non idempotent request without leader",
+			attempt:   1,
+			failure:   RequestFailure{Method: "POST", Path: marathonAPIApps, StatusCode: 503, LeaderNotElected: true},
+			wantDelay: 100 * time.Millisecond,
+			wantRetry: true,
+		},
+		{
+			name:      "retry after",
+			attempt:   1,
+			failure:   RequestFailure{Method: "GET", StatusCode: 503, RetryAfter: 500 * time.Millisecond},
+			wantDelay: 500 * time.Millisecond,
+			wantRetry: true,
+		},
+		{
+			name:      "retry after capped",
+			attempt:   1,
+			failure:   RequestFailure{Method: "GET", StatusCode: 429, RetryAfter: time.Minute},
+			wantDelay: time.Second,
+			wantRetry: true,
+		},
+	}
+
+	for _, test := range tests {
+		delay, retry := policy.RetryDelay(test.attempt, &test.failure)
+		assert.Equal(t, test.wantRetry, retry, test.name)
+		if test.wantRetry {
+			assert.Equal(t, test.wantDelay, delay, test.name)
+		}
+	}
+}
+
+func TestExponentialRetryPolicyJitter(t *testing.T) {
+	policy := NewExponentialRetryPolicy()
+	for i := 0; i < 100; i++ {
+		delay, retry := policy.RetryDelay(1, &RequestFailure{Method: "GET", StatusCode: 500})
+		require.True(t, retry)
+		assert.True(t, delay >= 80*time.Millisecond && delay <= 120*time.Millisecond, "delay %s out of bounds", delay)
+	}
+}
+
+func TestIsIdempotent(t *testing.T) {
+	assert.True(t, IsIdempotent("GET", marathonAPIApps))
+	assert.True(t, IsIdempotent("DELETE", marathonAPIApps+"/foo"))
+	assert.True(t, IsIdempotent("POST", marathonAPISubscription+"?callbackUrl=http://localhost"))
+	assert.False(t, IsIdempotent("POST", marathonAPIApps))
+	assert.False(t, IsIdempotent("PATCH", marathonAPIApps))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Equal(t, time.Duration(0), parseRetryAfter(""))
+	assert.Equal(t, 3*time.Second, parseRetryAfter("3"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("garbage"))
+
+	delay := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.True(t, delay > 50*time.Second && delay <= time.Minute, "unexpected delay %s", delay)
+}
+
+func TestAPICallRetryLeaderNotElected(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			http.Error(w, `{"message": "Could not determine the current leader"}`, http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"apps": []}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		URL: server.URL,
+		RetryPolicy: &ExponentialRetryPolicy{
+			MaxAttempts:    5,
+			InitialBackoff: time.Millisecond,
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = client.Applications(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Empty(t, client.(*marathonClient).hosts.nonActiveMembers())
+}
+
+func TestAPICallRetryNonIdempotent(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/"+marathonAPILeader {
+			http.Error(w, `{"message": "not found"}`, http.StatusNotFound)
+			return
+		}
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, `{"message": "internal error"}`, http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		URL:         server.URL + "," + server.URL,
+		RetryPolicy: NewExponentialRetryPolicy(),
+	})
+	require.NoError(t, err)
+
+	_, err = client.CreateApplication(NewDockerApplication())
+	if assert.Error(t, err) {
+		apiErr, ok := err.(*APIError)
+		if assert.True(t, ok) {
+			assert.Equal(t, ErrCodeServer, apiErr.ErrCode)
+		}
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
@@ -17,13 +17,18 @@ limitations under the License.
 package marathon
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/donovanhide/eventsource"
@@ -34,35 +39,46 @@ type Subscriptions struct {
 	CallbackURLs []string `json:"callbackUrls"`
 }
 
+// errEventStreamFilterChanged ends an event stream asking for other events than the listeners want
+var errEventStreamFilterChanged = errors.New("the events of the listeners changed")
+
+// eventTimestampFormat is the format of the timestamps of the events
+const eventTimestampFormat = "2006-01-02T15:04:05.000Z"
+
+// EventStreamStatus is the status of the SSE event stream
+type EventStreamStatus struct {
+	// Connected reports whether the stream is connected
+	Connected bool
+	// Member is the member the stream is, or was last, connected to
+	Member string
+	// Since is when the stream connected or disconnected
+	Since time.Time
+	// Error is the failure which disconnected the stream
+	Error string
+	// Reconnects is the number of times the stream connected again after a disconnection
+	Reconnects int
+}
+
 // Subscriptions retrieves a list of registered subscriptions
 func (r *marathonClient) Subscriptions() (*Subscriptions, error) {
+	return r.SubscriptionsContext(context.Background())
+}
+
+// SubscriptionsContext is like Subscriptions but uses the given context
+func (r *marathonClient) SubscriptionsContext(ctx context.Context) (*Subscriptions, error) {
 	subscriptions := new(Subscriptions)
-	if err := r.apiGet(marathonAPISubscription, nil, subscriptions); err != nil {
+	if err := r.apiGet(ctx, marathonAPISubscription, nil, subscriptions); err != nil {
 		return nil, err
 	}
 
 	return subscriptions, nil
 }
 
-// AddEventsListener adds your self as a listener to events from Marathon
+// AddEventsListener adds your self as a listener to events from Marathon. The events are queued
+// for the listener in order, see AddEventsListenerWithOptions for the settings of the queue.
 //		channel:	a EventsChannel used to receive event on
 func (r *marathonClient) AddEventsListener(filter int) (EventsChannel, error) {
-	r.Lock()
-	defer r.Unlock()
-
-	// step: someone has asked to start listening to event, we need to register for events
-	// if we haven't done so already
-	if err := r.registerSubscription(); err != nil {
-		return nil, err
-	}
-
-	channel := make(EventsChannel)
-	r.listeners[channel] = EventsChannelContext{
-		filter:     filter,
-		done:       make(chan struct{}, 1),
-		completion: &sync.WaitGroup{},
-	}
-	return channel, nil
+	return r.AddEventsListenerWithOptions(filter, ListenerOptions{})
 }
 
 // RemoveEventsListener removes the channel from the events listeners
@@ -71,21 +87,27 @@ func (r *marathonClient) RemoveEventsListener(channel EventsChannel) {
 	r.Lock()
 	defer r.Unlock()
 
-	if context, found := r.listeners[channel]; found {
-		close(context.done)
-		delete(r.listeners, channel)
+	if r.removeEventsListener(channel) {
 		// step: if there is no one else listening, let's remove ourselves
 		// from the events callback
 		if r.config.EventsTransport == EventsTransportCallback && len(r.listeners) == 0 {
 			r.Unsubscribe(r.SubscriptionURL())
 		}
+	}
+}
 
-		// step: wait for pending goroutines to finish and close channel
-		go func(completion *sync.WaitGroup) {
-			completion.Wait()
-			close(channel)
-		}(context.completion)
+// removeEventsListener stops the delivery of events to the channel, which is closed once the pending
+// delivery is done. It returns false if the channel is no listener. The caller must hold the lock.
+func (r *marathonClient) removeEventsListener(channel EventsChannel) bool {
+	listener, found := r.listeners[channel]
+	if !found {
+		return false
 	}
+	close(listener.done)
+	delete(r.listeners, channel)
+	r.eventStreamFilterChanged()
+
+	return true
 }
 
 // SubscriptionURL retrieves the subscription callback URL used when registering
@@ -94,7 +116,83 @@ func (r *marathonClient) SubscriptionURL() string {
 		return fmt.Sprintf("%s%s", r.config.CallbackURL, defaultEventsURL)
 	}
 
-	return fmt.Sprintf("http://%s:%d%s", r.ipAddress, r.config.EventsPort, defaultEventsURL)
+	scheme := "http"
+	if r.config.EventsTLSConfig != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, r.callbackAddress, defaultEventsURL)
+}
+
+// CallbackHandler returns the handler of the callback events, to be mounted on the path of the
+// subscription URL
+func (r *marathonClient) CallbackHandler() http.Handler {
+	return http.HandlerFunc(r.handleCallbackEvent)
+}
+
+// startCallbackServer starts the server of the callback events and sets the address advertised
+// to Marathon
+func (r *marathonClient) startCallbackServer() error {
+	// step: listen on the address of the interface, unless a listen address is given
+	address := r.config.EventsListenAddress
+	if address == "" {
+		ipAddress, err := getInterfaceAddress(r.config.EventsInterface)
+		if err != nil {
+			return fmt.Errorf("Unable to get the ip address from the interface: %s, error: %s",
+				r.config.EventsInterface, err)
+		}
+		address = net.JoinHostPort(ipAddress, strconv.Itoa(r.config.EventsPort))
+	}
+
+	// @todo need to add a timeout value here
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("unable to listen for the callback events on %s, error: %s", address, err)
+	}
+
+	// step: advertise the address listened on, with the port picked when listening on port 0
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	if advertised := r.config.EventsAdvertiseAddress; advertised != "" {
+		r.callbackAddress = advertised
+		if _, _, err := net.SplitHostPort(advertised); err != nil {
+			r.callbackAddress = net.JoinHostPort(advertised, port)
+		}
+	} else {
+		if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+			if host, err = getInterfaceAddress(r.config.EventsInterface); err != nil {
+				listener.Close()
+				return fmt.Errorf("Unable to get the ip address from the interface: %s, error: %s",
+					r.config.EventsInterface, err)
+			}
+		}
+		r.callbackAddress = net.JoinHostPort(host, port)
+	}
+
+	if r.config.EventsTLSConfig != nil {
+		listener = tls.NewListener(listener, r.config.EventsTLSConfig)
+	}
+
+	// step: register the handler
+	mux := http.NewServeMux()
+	mux.HandleFunc(defaultEventsURL, r.handleCallbackEvent)
+	// step: create the http server
+	r.eventsHTTP = &http.Server{
+		Addr:           address,
+		Handler:        mux,
+		ReadTimeout:    10 * time.Second,
+		WriteTimeout:   10 * time.Second,
+		MaxHeaderBytes: 1 << 20,
+	}
+
+	r.background.Add(1)
+	go func() {
+		defer r.background.Done()
+		// note: the listener is of no use anymore once serving failed
+		if err := r.eventsHTTP.Serve(listener); err != http.ErrServerClosed {
+			r.logger.Error("the callback server failed", "address", listener.Addr().String(), "error", err)
+		}
+	}()
+
+	return nil
 }
 
 // registerSubscription registers ourselves with Marathon to receive events from configured transport facility
@@ -109,39 +207,17 @@ func (r *marathonClient) registerSubscription() error {
 	}
 }
 
+// registerCallbackSubscription starts the callback server, unless the callbacks are served by an
+// external one, and subscribes its URL
 func (r *marathonClient) registerCallbackSubscription() error {
-	if r.eventsHTTP == nil {
-		ipAddress, err := getInterfaceAddress(r.config.EventsInterface)
-		if err != nil {
-			return fmt.Errorf("Unable to get the ip address from the interface: %s, error: %s",
-				r.config.EventsInterface, err)
-		}
-
-		// step: set the ip address
-		r.ipAddress = ipAddress
-		binding := fmt.Sprintf("%s:%d", ipAddress, r.config.EventsPort)
-		// step: register the handler
-		http.HandleFunc(defaultEventsURL, r.handleCallbackEvent)
-		// step: create the http server
-		r.eventsHTTP = &http.Server{
-			Addr:           binding,
-			Handler:        nil,
-			ReadTimeout:    10 * time.Second,
-			WriteTimeout:   10 * time.Second,
-			MaxHeaderBytes: 1 << 20,
+	if r.config.EventsExternalServer {
+		if r.config.CallbackURL == "" {
+			return errors.New("the callback URL must be set when the callback events are served by an external server")
 		}
-
-		// @todo need to add a timeout value here
-		listener, err := net.Listen("tcp", binding)
-		if err != nil {
-			return nil
+	} else if r.eventsHTTP == nil {
+		if err := r.startCallbackServer(); err != nil {
+			return err
 		}
-
-		go func() {
-			for {
-				r.eventsHTTP.Serve(listener)
-			}
-		}()
 	}
 
 	// step: get the callback url
@@ -165,7 +241,9 @@ func (r *marathonClient) registerCallbackSubscription() error {
 // registerSSESubscription starts a go routine that continuously tries to
 // connect to the SSE stream and to process the received events. To establish
 // the connection it tries the active cluster members until no more member is
-// active. When this happens it will retry to get a connection every 5 seconds.
+// active. When this happens it retries with the growing delays of the
+// EventStreamBackoff. Listeners are told of the connections and disconnections
+// by the stream lifecycle events. The go routine stops when the client is closed.
 func (r *marathonClient) registerSSESubscription() error {
 	if r.subscribedToSSE {
 		return nil
@@ -178,17 +256,67 @@ func (r *marathonClient) registerSSESubscription() error {
 		)
 	}
 
+	backoff := r.config.EventStreamBackoff
+	if backoff == nil {
+		backoff = defaultEventStreamBackoff()
+	}
+
+	r.background.Add(1)
 	go func() {
+		defer r.background.Done()
+		// note: the attempts count the failed connections and the short-lived streams in a row,
+		// the first one is retried right away
+		attempt := 0
+		var disconnected time.Time
 		for {
-			stream, err := r.connectToSSE()
+			if attempt > 1 {
+				select {
+				case <-r.ctx.Done():
+					return
+				case <-time.After(backoff.backoff(attempt - 1)):
+				}
+			}
+			if attempt > 0 {
+				r.client.metrics().EventStreamReconnect()
+			}
+
+			path := r.eventStreamPath()
+			ctx, cancel := context.WithCancel(r.ctx)
+			stream, member, err := r.connectToSSE(ctx, path)
 			if err != nil {
-				r.debugLog("Error connecting SSE subscription: %s", err)
-				<-time.After(5 * time.Second)
+				cancel()
+				if r.ctx.Err() != nil {
+					return
+				}
+				r.logger.Error("failed to connect the event stream", "path", path, "error", err)
+				attempt++
 				continue
 			}
-			err = r.listenToSSE(stream)
+			connected := time.Now()
+			r.streamConnected(member, connected, disconnected)
+
+			// note: closing the client cancels the stream request, which
+			// surfaces as an error of the stream
+			err = r.listenToSSE(stream, path, cancel)
 			stream.Close()
-			r.debugLog("Error on SSE subscription: %s", err)
+			cancel()
+			if r.ctx.Err() != nil {
+				return
+			}
+			disconnected = time.Now()
+			r.streamDisconnected(member, disconnected, err)
+
+			switch {
+			case err == errEventStreamFilterChanged:
+				r.logger.Info("event stream filter changed, reconnecting", "path", path, "member", member)
+				attempt = 0
+			case disconnected.Sub(connected) >= stableEventStreamDuration:
+				r.logger.Warn("event stream failed, reconnecting", "path", path, "member", member, "error", err)
+				attempt = 1
+			default:
+				r.logger.Warn("event stream failed, reconnecting", "path", path, "member", member, "error", err)
+				attempt++
+			}
 		}
 	}()
 
@@ -196,26 +324,98 @@ func (r *marathonClient) registerSSESubscription() error {
 	return nil
 }
 
+// streamConnected records the connection of the event stream and tells the listeners
+func (r *marathonClient) streamConnected(member string, now, disconnected time.Time) {
+	var gap time.Duration
+	if !disconnected.IsZero() {
+		gap = now.Sub(disconnected)
+	}
+	r.logger.Info("event stream connected", "member", member, "gap", gap)
+
+	r.Lock()
+	r.streamStatus.Connected = true
+	r.streamStatus.Member = member
+	r.streamStatus.Since = now
+	r.streamStatus.Error = ""
+	if !disconnected.IsZero() {
+		r.streamStatus.Reconnects++
+	}
+	r.Unlock()
+
+	event, _ := GetEvent("stream_connected_event")
+	event.Event = &EventStreamConnected{
+		EventType: event.Name,
+		Timestamp: now.UTC().Format(eventTimestampFormat),
+		Member:    member,
+		Gap:       gap,
+	}
+	r.dispatchEvent(event)
+}
+
+// streamDisconnected records the disconnection of the event stream and tells the listeners
+func (r *marathonClient) streamDisconnected(member string, now time.Time, err error) {
+	var message string
+	if err != nil {
+		message = err.Error()
+	}
+
+	r.Lock()
+	r.streamStatus.Connected = false
+	r.streamStatus.Since = now
+	r.streamStatus.Error = message
+	r.Unlock()
+
+	event, _ := GetEvent("stream_disconnected_event")
+	event.Event = &EventStreamDisconnected{
+		EventType: event.Name,
+		Timestamp: now.UTC().Format(eventTimestampFormat),
+		Member:    member,
+		Error:     message,
+	}
+	r.dispatchEvent(event)
+}
+
+// EventStreamStatus returns the status of the SSE event stream
+func (r *marathonClient) EventStreamStatus() EventStreamStatus {
+	r.RLock()
+	defer r.RUnlock()
+	return r.streamStatus
+}
+
 // connectToSSE tries to establish an *eventsource.Stream to any of the Marathon cluster members, marking the
-// member as down on connection failure, until there is no more active member in the cluster.
+// member as down on connection failure, until there is no more active member in the cluster. It returns the
+// member the stream is connected to.
 // Given the http request can not be built, it will panic as this case should never happen.
-func (r *marathonClient) connectToSSE() (*eventsource.Stream, error) {
+func (r *marathonClient) connectToSSE(ctx context.Context, path string) (*eventsource.Stream, string, error) {
 	for {
-		request, member, err := r.buildAPIRequest("GET", marathonAPIEventStream, nil)
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+
+		request, member, err := r.buildAPIRequest(ctx, "GET", path, nil)
 		if err != nil {
 			switch err.(type) {
 			case newRequestError:
 				panic(fmt.Sprintf("Requests for SSE subscriptions should never fail to be created: %s", err.Error()))
 			default:
-				return nil, err
+				return nil, "", err
 			}
 		}
 
 		// The event source library manipulates the HTTPClient. So we create a new one and copy
 		// its underlying fields for performance reasons. See note that at least the Transport
 		// should be reused here: https://golang.org/pkg/net/http/#Client
+		transport := r.config.HTTPSSEClient.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
 		httpClient := &http.Client{
-			Transport:     r.config.HTTPSSEClient.Transport,
+			Transport: &middlewareTransport{
+				client: r.client,
+				base:   transport,
+				member: member,
+				path:   path,
+			},
 			CheckRedirect: r.config.HTTPSSEClient.CheckRedirect,
 			Jar:           r.config.HTTPSSEClient.Jar,
 			Timeout:       r.config.HTTPSSEClient.Timeout,
@@ -223,49 +423,140 @@ func (r *marathonClient) connectToSSE() (*eventsource.Stream, error) {
 
 		stream, err := eventsource.SubscribeWith("", httpClient, request)
 		if err != nil {
-			r.debugLog("Error subscribing to Marathon event stream: %s", err)
-			r.hosts.markDown(member)
+			if ctx.Err() != nil {
+				return nil, "", ctx.Err()
+			}
+			if isDCOSLoginError(err) {
+				return nil, "", err
+			}
+			r.logger.Warn("failed to connect the event stream", "path", path, "member", member, "error", err)
+			r.hosts.markDownWithReason(member, err.Error())
 			continue
 		}
 
-		return stream, nil
+		return stream, member, nil
 	}
 }
 
-func (r *marathonClient) listenToSSE(stream *eventsource.Stream) error {
+// listenToSSE handles the events of the stream until it fails, or until the listeners want other
+// events than the path of the stream asks for, which cancels the stream request
+func (r *marathonClient) listenToSSE(stream *eventsource.Stream, path string, cancel context.CancelFunc) error {
+	filterChanged := false
 	for {
 		select {
 		case ev := <-stream.Events:
-			if err := r.handleEvent(ev.Data()); err != nil {
-				r.debugLog("listenToSSE(): failed to handle event: %v", err)
-			}
+			// note: failures are logged by handleEvent
+			r.handleEvent(ev.Data())
 		case err := <-stream.Errors:
+			if filterChanged {
+				return errEventStreamFilterChanged
+			}
 			return err
+		case <-r.streamFilterChanged:
+			// note: the stream may only be closed once it surfaced its error, the
+			// cancellation of the request is waited for
+			if !filterChanged && r.eventStreamPath() != path {
+				filterChanged = true
+				cancel()
+			}
+		}
+	}
+}
+
+// eventStreamPath returns the path of the event stream, asking Marathon for the events the
+// listeners want only, and for the plan format of the configuration
+func (r *marathonClient) eventStreamPath() string {
+	r.RLock()
+	wanted := r.eventStreamTypes()
+	r.RUnlock()
+
+	query := url.Values{}
+	if r.config.EventsLightPlanFormat {
+		query.Set("plan-format", "light")
+	}
+	if len(wanted) > 0 {
+		query["event_type"] = wanted
+	}
+
+	if len(query) == 0 {
+		return marathonAPIEventStream
+	}
+	return marathonAPIEventStream + "?" + query.Encode()
+}
 
+// noEventStreamType is the event type the SSE stream asks for when the listeners want the pseudo
+// events only, which no Marathon event is of so that the stream carries none
+const noEventStreamType = "no_event"
+
+// eventStreamTypes returns the sorted event types the SSE stream asks for the listeners, none for all
+// of them. The caller must hold the lock.
+func (r *marathonClient) eventStreamTypes() []string {
+	filter := 0
+	for _, listener := range r.listeners {
+		filter |= listener.filter
+	}
+
+	// step: the pseudo events are not sent by Marathon, the stream is left unfiltered when
+	// the unknown events are wanted
+	if filter&EventIDUnknown != 0 {
+		return nil
+	}
+	var wanted []string
+	for eventType, id := range eventTypes() {
+		if id&filter != 0 && id&EventIDStreamLifecycle == 0 {
+			wanted = append(wanted, eventType)
 		}
 	}
+	if len(wanted) == 0 && filter != 0 {
+		return []string{noEventStreamType}
+	}
+	sort.Strings(wanted)
+	return wanted
+}
+
+// eventStreamFilterChanged tells the event stream the listeners changed. The caller must hold the lock.
+func (r *marathonClient) eventStreamFilterChanged() {
+	select {
+	case r.streamFilterChanged <- struct{}{}:
+	default:
+	}
 }
 
 // Subscribe adds a URL to Marathon's callback facility
 //	callback	: the URL you wish to subscribe
 func (r *marathonClient) Subscribe(callback string) error {
+	return r.SubscribeContext(context.Background(), callback)
+}
+
+// SubscribeContext is like Subscribe but uses the given context
+func (r *marathonClient) SubscribeContext(ctx context.Context, callback string) error {
 	path := fmt.Sprintf("%s?callbackUrl=%s", marathonAPISubscription, callback)
-	return r.ApiPost(path, "", nil)
+	return r.apiPost(ctx, path, "", nil)
 
 }
 
 // Unsubscribe removes a URL from Marathon's callback facility
 //	callback	: the URL you wish to unsubscribe
 func (r *marathonClient) Unsubscribe(callback string) error {
+	return r.UnsubscribeContext(context.Background(), callback)
+}
+
+// UnsubscribeContext is like Unsubscribe but uses the given context
+func (r *marathonClient) UnsubscribeContext(ctx context.Context, callback string) error {
 	// step: remove from the list of subscriptions
-	return r.apiDelete(fmt.Sprintf("%s?callbackUrl=%s", marathonAPISubscription, callback), nil, nil)
+	return r.apiDelete(ctx, fmt.Sprintf("%s?callbackUrl=%s", marathonAPISubscription, callback), nil, nil)
 }
 
 // HasSubscription checks to see a subscription already exists with Marathon
 //		callback:			the url of the callback
 func (r *marathonClient) HasSubscription(callback string) (bool, error) {
+	return r.HasSubscriptionContext(context.Background(), callback)
+}
+
+// HasSubscriptionContext is like HasSubscription but uses the given context
+func (r *marathonClient) HasSubscriptionContext(ctx context.Context, callback string) (bool, error) {
 	// step: generate our events callback
-	subscriptions, err := r.Subscriptions()
+	subscriptions, err := r.SubscriptionsContext(ctx)
 	if err != nil {
 		return false, err
 	}
@@ -280,57 +571,90 @@ func (r *marathonClient) HasSubscription(callback string) (bool, error) {
 }
 
 func (r *marathonClient) handleEvent(content string) error {
+	return r.processEvent(content, true)
+}
+
+// processEvent decodes the event and queues it for the listeners, recording it first if asked
+func (r *marathonClient) processEvent(content string, record bool) error {
 	// step: process and decode the event
+	metrics := r.client.metrics()
 	eventType := new(EventType)
 	err := json.NewDecoder(strings.NewReader(content)).Decode(eventType)
+	// note: the malformed events are recorded too, they are the ones to look into
+	if record {
+		r.recordEvent(eventType.EventType, content)
+	}
 	if err != nil {
+		metrics.EventDropped("unknown")
+		r.logger.Warn("failed to decode the event type", "content", content, "error", err)
 		return fmt.Errorf("failed to decode the event type, content: %s, error: %s", content, err)
 	}
+	metrics.EventReceived(eventType.EventType)
+	r.logger.Debug("event received", "event_type", eventType.EventType)
 
-	// step: check whether event type is handled
-	event, err := GetEvent(eventType.EventType)
+	// step: let's decode message, the unknown types are passed on raw
+	event, err := decodeEvent(eventType.EventType, []byte(content))
 	if err != nil {
-		return fmt.Errorf("unable to handle event, type: %s, error: %s", eventType.EventType, err)
+		metrics.EventDropped(eventType.EventType)
+		r.logger.Warn("failed to decode the event", "event_type", eventType.EventType, "error", err)
+		return fmt.Errorf("failed to decode the event, type: %s, error: %s", eventType.EventType, err)
 	}
 
-	// step: let's decode message
-	err = json.NewDecoder(strings.NewReader(content)).Decode(event.Event)
-	if err != nil {
-		return fmt.Errorf("failed to decode the event, id: %d, error: %s", event.ID, err)
-	}
+	r.dispatchEvent(event)
 
-	r.RLock()
-	defer r.RUnlock()
+	return nil
+}
+
+// dispatchEvent queues the event for the listeners which want it
+func (r *marathonClient) dispatchEvent(event *Event) {
+	metrics := r.client.metrics()
 
 	// step: check if anyone is listen for this event
-	for channel, context := range r.listeners {
-		// step: check if this listener wants this event type
-		if event.ID&context.filter != 0 {
-			context.completion.Add(1)
-			go func(ch EventsChannel, context EventsChannelContext, e *Event) {
-				defer context.completion.Done()
-				select {
-				case ch <- e:
-				case <-context.done:
-					// Terminates goroutine.
-				}
-			}(channel, context, event)
+	r.RLock()
+	listeners := make(map[EventsChannel]EventsChannelContext)
+	for channel, listener := range r.listeners {
+		// step: check if this listener wants this event type and matches its predicates
+		if listener.wants(event) {
+			listeners[channel] = listener
+		}
+	}
+	var waiters []*eventWaiter
+	for waiter := range r.waiters {
+		if event.ID&waiter.filter != 0 || eventDeploymentPlan(event) != nil {
+			waiters = append(waiters, waiter)
 		}
 	}
+	r.RUnlock()
 
-	return nil
+	for _, waiter := range waiters {
+		waiter.notify(event)
+	}
+
+	// note: the lock is not held while queueing, which may block until the listener is removed
+	for channel, listener := range listeners {
+		if !listener.enqueue(event, metrics) {
+			r.logger.Warn("events listener overflowed, disconnecting it", "listener", listener.id, "event_type", event.Name)
+			r.RemoveEventsListener(channel)
+		}
+	}
 }
 
 func (r *marathonClient) handleCallbackEvent(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != "POST" {
+		writer.Header().Set("Allow", "POST")
+		http.Error(writer, "the events must be posted", http.StatusMethodNotAllowed)
+		return
+	}
+
 	body, err := ioutil.ReadAll(request.Body)
 	if err != nil {
-		// TODO should this return a 500?
-		r.debugLog("handleCallbackEvent(): failed to read request body, error: %s", err)
+		r.logger.Error("failed to read the callback event", "error", err)
+		http.Error(writer, "failed to read the event", http.StatusBadRequest)
 		return
 	}
 
+	// note: failures are logged by handleEvent, and the error holds the content of the event
 	if err := r.handleEvent(string(body[:])); err != nil {
-		// TODO should this return a 500?
-		r.debugLog("handleCallbackEvent(): failed to handle event: %v", err)
+		http.Error(writer, "failed to decode the event", http.StatusBadRequest)
 	}
 }
@@ -17,8 +17,15 @@ limitations under the License.
 package marathon
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -133,6 +140,149 @@ var testCases = testCaseList{
 			},
 		},
 	},
+	testCase{
+		name: "pod_created_event",
+		source: `{
+	"eventType": "pod_created_event",
+	"timestamp": "2017-07-13T21:33:07.389Z",
+	"clientIp": "10.0.0.1",
+	"uri": "/v2/pods"
+}`,
+		expectation: &EventPodCreated{
+			EventType: "pod_created_event",
+			Timestamp: "2017-07-13T21:33:07.389Z",
+			ClientIP:  "10.0.0.1",
+			URI:       "/v2/pods",
+		},
+	},
+	testCase{
+		name: "pod_updated_event",
+		source: `{
+	"eventType": "pod_updated_event",
+	"timestamp": "2017-07-13T21:33:07.389Z",
+	"clientIp": "10.0.0.1",
+	"uri": "/v2/pods/fake-pod"
+}`,
+		expectation: &EventPodUpdated{
+			EventType: "pod_updated_event",
+			Timestamp: "2017-07-13T21:33:07.389Z",
+			ClientIP:  "10.0.0.1",
+			URI:       "/v2/pods/fake-pod",
+		},
+	},
+	testCase{
+		name: "pod_deleted_event",
+		source: `{
+	"eventType": "pod_deleted_event",
+	"timestamp": "2017-07-13T21:33:07.389Z",
+	"clientIp": "10.0.0.1",
+	"uri": "/v2/pods/fake-pod"
+}`,
+		expectation: &EventPodDeleted{
+			EventType: "pod_deleted_event",
+			Timestamp: "2017-07-13T21:33:07.389Z",
+			ClientIP:  "10.0.0.1",
+			URI:       "/v2/pods/fake-pod",
+		},
+	},
+	testCase{
+		name: "instance_changed_event",
+		source: `{
+	"eventType": "instance_changed_event",
+	"timestamp": "2017-07-13T21:33:07.389Z",
+	"instanceId": "fake-pod.instance-c3d7e2a7-6817-11e7-b0e8-0242f8c1d9e5",
+	"condition": "Running",
+	"runSpecId": "/fake-pod",
+	"runSpecVersion": "2017-07-13T21:33:07.389Z",
+	"agentId": "4f1c3b5a-1f4b-4e0c-b4b8-1f4b4e0cb4b8-S0",
+	"host": "10.0.0.2"
+}`,
+		expectation: &EventInstanceChanged{
+			EventType:      "instance_changed_event",
+			Timestamp:      "2017-07-13T21:33:07.389Z",
+			InstanceID:     "fake-pod.instance-c3d7e2a7-6817-11e7-b0e8-0242f8c1d9e5",
+			Condition:      "Running",
+			RunSpecID:      "/fake-pod",
+			RunSpecVersion: "2017-07-13T21:33:07.389Z",
+			AgentID:        "4f1c3b5a-1f4b-4e0c-b4b8-1f4b4e0cb4b8-S0",
+			Host:           "10.0.0.2",
+		},
+	},
+	testCase{
+		name: "instance_health_changed_event",
+		source: `{
+	"eventType": "instance_health_changed_event",
+	"timestamp": "2017-07-13T21:33:07.389Z",
+	"instanceId": "fake-pod.instance-c3d7e2a7-6817-11e7-b0e8-0242f8c1d9e5",
+	"runSpecId": "/fake-pod",
+	"runSpecVersion": "2017-07-13T21:33:07.389Z",
+	"healthy": true
+}`,
+		expectation: &EventInstanceHealthChanged{
+			EventType:      "instance_health_changed_event",
+			Timestamp:      "2017-07-13T21:33:07.389Z",
+			InstanceID:     "fake-pod.instance-c3d7e2a7-6817-11e7-b0e8-0242f8c1d9e5",
+			RunSpecID:      "/fake-pod",
+			RunSpecVersion: "2017-07-13T21:33:07.389Z",
+			Healthy:        func(healthy bool) *bool { return &healthy }(true),
+		},
+	},
+	testCase{
+		name: "unknown_instance_terminated_event",
+		source: `{
+	"eventType": "unknown_instance_terminated_event",
+	"timestamp": "2017-07-13T21:33:07.389Z",
+	"instanceId": "fake-pod.instance-c3d7e2a7-6817-11e7-b0e8-0242f8c1d9e5",
+	"runSpecId": "/fake-pod",
+	"condition": "Killed"
+}`,
+		expectation: &EventUnknownInstanceTerminated{
+			EventType:  "unknown_instance_terminated_event",
+			Timestamp:  "2017-07-13T21:33:07.389Z",
+			InstanceID: "fake-pod.instance-c3d7e2a7-6817-11e7-b0e8-0242f8c1d9e5",
+			RunSpecID:  "/fake-pod",
+			Condition:  "Killed",
+		},
+	},
+	testCase{
+		name: "scheduler_registered_event",
+		source: `{
+	"eventType": "scheduler_registered_event",
+	"timestamp": "2017-07-13T21:33:07.389Z",
+	"frameworkId": "4f1c3b5a-1f4b-4e0c-b4b8-1f4b4e0cb4b8-0001",
+	"master": "10.0.0.3"
+}`,
+		expectation: &EventSchedulerRegistered{
+			EventType:   "scheduler_registered_event",
+			Timestamp:   "2017-07-13T21:33:07.389Z",
+			FrameworkID: "4f1c3b5a-1f4b-4e0c-b4b8-1f4b4e0cb4b8-0001",
+			Master:      "10.0.0.3",
+		},
+	},
+	testCase{
+		name: "scheduler_reregistered_event",
+		source: `{
+	"eventType": "scheduler_reregistered_event",
+	"timestamp": "2017-07-13T21:33:07.389Z",
+	"masterId": "10.0.0.3"
+}`,
+		expectation: &EventSchedulerReregistered{
+			EventType: "scheduler_reregistered_event",
+			Timestamp: "2017-07-13T21:33:07.389Z",
+			MasterID:  "10.0.0.3",
+		},
+	},
+	testCase{
+		name: "scheduler_disconnected_event",
+		source: `{
+	"eventType": "scheduler_disconnected_event",
+	"timestamp": "2017-07-13T21:33:07.389Z"
+}`,
+		expectation: &EventSchedulerDisconnected{
+			EventType: "scheduler_disconnected_event",
+			Timestamp: "2017-07-13T21:33:07.389Z",
+		},
+	},
 	// For Marathon 1.1.1 and before
 	testCase{
 		name: "deployment_info",
@@ -232,8 +382,8 @@ var testCases = testCaseList{
 		]
 	}
 }`,
-		expectation: &EventDeploymentInfo{
-			EventType: "deployment_info",
+		expectation: &EventDeploymentStepSuccess{
+			EventType: "deployment_step_success",
 			Timestamp: "2016-07-29T08:03:52.542Z",
 			Plan: &DeploymentPlan{
 				ID:       "dcf63e4a-ef27-4816-e865-1730fcb26ac3",
@@ -325,7 +475,8 @@ func TestEventStreamEventsReceived(t *testing.T) {
 	endpoint := newFakeMarathonEndpoint(t, &config)
 	defer endpoint.Close()
 
-	events, err := endpoint.Client.AddEventsListener(EventIDApplications | EventIDDeploymentInfo | EventIDDeploymentStepSuccess)
+	events, err := endpoint.Client.AddEventsListener(EventIDApplications | EventIDDeploymentInfo | EventIDDeploymentStepSuccess |
+		EventIDPods | EventIDInstances | EventIDScheduler)
 	assert.NoError(t, err)
 
 	almostAllTestCases := testCases[:len(testCases)-1]
@@ -389,7 +540,7 @@ func TestConnectToSSESuccess(t *testing.T) {
 	client.hosts.members = append(client.hosts.members, &member{endpoint: endpoint.Server.httpSrv.URL})
 
 	// Connection should work as one of the Marathon members is up
-	stream, err := client.connectToSSE()
+	stream, _, err := client.connectToSSE(context.Background(), marathonAPIEventStream)
 	if assert.NoError(t, err, "expected no error in connectToSSE") {
 		stream.Close()
 	}
@@ -406,7 +557,7 @@ func TestConnectToSSEFailure(t *testing.T) {
 	client := endpoint.Client.(*marathonClient)
 
 	// No Marathon member is up, we should get an error
-	stream, err := client.connectToSSE()
+	stream, _, err := client.connectToSSE(context.Background(), marathonAPIEventStream)
 	if !assert.Error(t, err, "expected error in connectToSSE when all cluster members are down") {
 		stream.Close()
 	}
@@ -457,3 +608,372 @@ func TestRegisterSEESubscriptionReconnectsStreamOnError(t *testing.T) {
 		assert.Fail(t, "did not receive event in time")
 	}
 }
+
+func TestEventStreamLifecycleEvents(t *testing.T) {
+	clientCfg := NewDefaultConfig()
+	clientCfg.EventsTransport = EventsTransportSSE
+	config := configContainer{client: &clientCfg}
+
+	endpoint1 := newFakeMarathonEndpoint(t, &config)
+	endpoint2 := newFakeMarathonEndpoint(t, &config)
+	defer endpoint2.Close()
+
+	client := endpoint1.Client.(*marathonClient)
+	client.hosts.members = append(client.hosts.members, &member{endpoint: endpoint2.Server.httpSrv.URL})
+	assert.Equal(t, EventStreamStatus{}, client.EventStreamStatus())
+
+	events, err := client.AddEventsListener(EventIDStreamLifecycle)
+	require.NoError(t, err)
+	next := func() *Event {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(eventPublishTimeout):
+			require.Fail(t, "did not receive event in time")
+		}
+		return nil
+	}
+
+	connected := next().Event.(*EventStreamConnected)
+	assert.Equal(t, "stream_connected_event", connected.EventType)
+	assert.Equal(t, endpoint1.Server.httpSrv.URL, connected.Member)
+	assert.Zero(t, connected.Gap)
+	status := client.EventStreamStatus()
+	assert.True(t, status.Connected)
+	assert.Equal(t, endpoint1.Server.httpSrv.URL, status.Member)
+	assert.Zero(t, status.Reconnects)
+
+	// step: the stream fails over to the second member
+	endpoint1.Close()
+	disconnected := next().Event.(*EventStreamDisconnected)
+	assert.Equal(t, endpoint1.Server.httpSrv.URL, disconnected.Member)
+	assert.NotEmpty(t, disconnected.Timestamp)
+	connected = next().Event.(*EventStreamConnected)
+	assert.Equal(t, endpoint2.Server.httpSrv.URL, connected.Member)
+	assert.True(t, connected.Gap > 0)
+
+	status = client.EventStreamStatus()
+	assert.True(t, status.Connected)
+	assert.Equal(t, endpoint2.Server.httpSrv.URL, status.Member)
+	assert.Equal(t, 1, status.Reconnects)
+	assert.Empty(t, status.Error)
+}
+
+func TestEventStreamBackoff(t *testing.T) {
+	backoff := defaultEventStreamBackoff()
+	backoff.Jitter = 0
+	assert.Equal(t, time.Second, backoff.backoff(1))
+	assert.Equal(t, 4*time.Second, backoff.backoff(3))
+	assert.Equal(t, time.Minute, backoff.backoff(10))
+}
+
+func TestEventStreamPath(t *testing.T) {
+	client := &marathonClient{listeners: make(map[EventsChannel]EventsChannelContext)}
+	assert.Equal(t, "v2/events", client.eventStreamPath())
+
+	client.listeners[make(EventsChannel)] = EventsChannelContext{filter: EventIDStatusUpdate | EventIDStreamLifecycle}
+	client.listeners[make(EventsChannel)] = EventsChannelContext{filter: EventIDDeploymentInfo}
+	assert.Equal(t, "v2/events?event_type=deployment_info&event_type=status_update_event", client.eventStreamPath())
+
+	client.config.EventsLightPlanFormat = true
+	assert.Equal(t, "v2/events?event_type=deployment_info&event_type=status_update_event&plan-format=light", client.eventStreamPath())
+
+	// step: the stream carries no event when the pseudo events only are wanted, all of them when
+	// the unknown events are
+	client.config.EventsLightPlanFormat = false
+	client.listeners = map[EventsChannel]EventsChannelContext{make(EventsChannel): {filter: EventIDStreamLifecycle}}
+	assert.Equal(t, "v2/events?event_type=no_event", client.eventStreamPath())
+	client.listeners = map[EventsChannel]EventsChannelContext{make(EventsChannel): {filter: EventIDStatusUpdate | EventIDUnknown}}
+	assert.Equal(t, "v2/events", client.eventStreamPath())
+}
+
+func TestEventStreamReconnectsOnFilterChange(t *testing.T) {
+	recorder := &callRecorder{}
+	clientCfg := NewDefaultConfig()
+	clientCfg.EventsTransport = EventsTransportSSE
+	clientCfg.Middlewares = []Middleware{recorder.middleware}
+	config := configContainer{client: &clientCfg}
+	endpoint := newFakeMarathonEndpoint(t, &config)
+	defer endpoint.Close()
+
+	_, err := endpoint.Client.AddEventsListener(EventIDStatusUpdate)
+	require.NoError(t, err)
+	time.Sleep(SSEConnectWaitTime)
+	events, err := endpoint.Client.AddEventsListener(EventIDDeploymentInfo | EventIDStreamConnected)
+	require.NoError(t, err)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "stream_connected_event", event.Name)
+	case <-time.After(eventPublishTimeout):
+		require.Fail(t, "did not reconnect in time")
+	}
+	assert.Equal(t, []string{
+		"v2/events?event_type=status_update_event",
+		"v2/events?event_type=deployment_info&event_type=status_update_event",
+	}, recorder.paths())
+
+	// step: the events are received on the new stream
+	endpoint.Server.PublishEvent(`{"eventType": "deployment_info", "timestamp": "2014-03-01T23:29:30.158Z"}`)
+	select {
+	case event := <-events:
+		assert.Equal(t, "deployment_info", event.Name)
+	case <-time.After(eventPublishTimeout):
+		assert.Fail(t, "did not receive event in time")
+	}
+}
+
+func TestHandleUnknownAndRegisteredEvents(t *testing.T) {
+	if strconv.IntSize == 32 {
+		t.Skip("no event listener ID is left to register event types on 32-bit platforms")
+	}
+	endpoint := newFakeMarathonEndpoint(t, nil)
+	defer endpoint.Close()
+	client := endpoint.Client.(*marathonClient)
+
+	type registeredEvent struct {
+		EventType string `json:"eventType"`
+		Count     int    `json:"count"`
+	}
+	id, err := RegisterEventType("test_registered_event", func(data []byte) (interface{}, error) {
+		event := new(registeredEvent)
+		return event, json.Unmarshal(data, event)
+	})
+	require.NoError(t, err)
+	_, err = RegisterEventType("test_registered_event", nil)
+	assert.Error(t, err)
+	_, err = RegisterEventType("status_update_event", nil)
+	assert.Error(t, err)
+	_, err = GetEvent("test_registered_event")
+	assert.Error(t, err)
+
+	// step: register a listener by hand so no subscription is made
+	client.Lock()
+	events := client.addEventsListener(EventIDUnknown|id, ListenerOptions{})
+	client.Unlock()
+	next := func() *Event {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(eventPublishTimeout):
+			require.Fail(t, "did not receive event in time")
+		}
+		return nil
+	}
+
+	content := `{"eventType": "instance_unmodelled_event", "instanceId": "instance"}`
+	require.NoError(t, client.handleEvent(content))
+	event := next()
+	assert.Equal(t, EventIDUnknown, event.ID)
+	assert.Equal(t, "instance_unmodelled_event", event.Name)
+	unknown := event.Event.(*EventUnknown)
+	assert.Equal(t, "instance_unmodelled_event", unknown.EventType)
+	assert.JSONEq(t, content, string(unknown.Data))
+
+	require.NoError(t, client.handleEvent(`{"eventType": "test_registered_event", "count": 3}`))
+	event = next()
+	assert.Equal(t, id, event.ID)
+	assert.Equal(t, &registeredEvent{EventType: "test_registered_event", Count: 3}, event.Event)
+	assert.Error(t, client.handleEvent(`{"eventType": "test_registered_event", "count": "three"}`))
+}
+
+func TestRegisterEventTypeExhausted(t *testing.T) {
+	eventTypesLock.Lock()
+	last := lastEventID
+	lastEventID = 1 << uint(strconv.IntSize-3)
+	eventTypesLock.Unlock()
+	defer func() {
+		eventTypesLock.Lock()
+		lastEventID = last
+		eventTypesLock.Unlock()
+	}()
+
+	id, err := RegisterEventType("test_last_registered_event", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1<<uint(strconv.IntSize-2), id)
+	_, err = RegisterEventType("test_exhausted_event", nil)
+	assert.Error(t, err)
+	_, found := eventTypeID("test_exhausted_event")
+	assert.False(t, found)
+}
+
+func TestCloseEventStream(t *testing.T) {
+	clientCfg := NewDefaultConfig()
+	clientCfg.EventsTransport = EventsTransportSSE
+	config := configContainer{client: &clientCfg}
+	endpoint := newFakeMarathonEndpoint(t, &config)
+	defer endpoint.Close()
+
+	events, err := endpoint.Client.AddEventsListener(EventIDApplications)
+	require.NoError(t, err)
+
+	// Give it a bit of time so that the subscription can be set up
+	time.Sleep(SSEConnectWaitTime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, endpoint.Client.Close(ctx))
+
+	select {
+	case _, more := <-events:
+		assert.False(t, more, "should not have received an event")
+	default:
+		assert.Fail(t, "channel was not closed")
+	}
+
+	_, err = endpoint.Client.AddEventsListener(EventIDApplications)
+	assert.Equal(t, ErrClientClosed, err)
+	assert.NoError(t, endpoint.Client.Close(ctx))
+}
+
+func TestCloseCallbackServer(t *testing.T) {
+	clientCfg := NewDefaultConfig()
+	clientCfg.EventsInterface = "lo"
+	clientCfg.EventsPort = 10101
+	config := configContainer{
+		client: &clientCfg,
+		server: &serverConfig{scope: "callback-lifecycle"},
+	}
+	endpoint := newFakeMarathonEndpoint(t, &config)
+	defer endpoint.Close()
+
+	events, err := endpoint.Client.AddEventsListener(EventIDApplications)
+	require.NoError(t, err)
+
+	callbackURL := endpoint.Client.(*marathonClient).SubscriptionURL()
+	response, err := http.Post(callbackURL, "application/json", strings.NewReader(testCases[0].source))
+	require.NoError(t, err)
+	response.Body.Close()
+
+	select {
+	case event := <-events:
+		assert.Equal(t, testCases[0].name, event.Name)
+	case <-time.After(eventPublishTimeout):
+		assert.Fail(t, "did not receive event in time")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, endpoint.Client.Close(ctx))
+
+	_, more := <-events
+	assert.False(t, more, "channel was not closed")
+
+	_, err = http.Post(callbackURL, "application/json", strings.NewReader(testCases[0].source))
+	assert.Error(t, err, "callback server should be shut down")
+}
+
+func TestCallbackHandler(t *testing.T) {
+	endpoint := newFakeMarathonEndpoint(t, nil)
+	defer endpoint.Close()
+	client := endpoint.Client.(*marathonClient)
+
+	// step: register a listener by hand so no subscription is made
+	client.Lock()
+	events := client.addEventsListener(EventIDApplications, ListenerOptions{})
+	client.Unlock()
+
+	post := func(method, body string) int {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(method, defaultEventsURL, strings.NewReader(body))
+		client.CallbackHandler().ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+	assert.Equal(t, http.StatusOK, post("POST", testCases[0].source))
+	select {
+	case event := <-events:
+		assert.Equal(t, testCases[0].name, event.Name)
+	case <-time.After(eventPublishTimeout):
+		assert.Fail(t, "did not receive event in time")
+	}
+
+	assert.Equal(t, http.StatusBadRequest, post("POST", "not json"))
+	assert.Equal(t, http.StatusBadRequest, post("POST", `{"eventType": "status_update_event", "ports": "none"}`))
+
+	// step: the content of the event is not echoed back
+	recorder := httptest.NewRecorder()
+	client.CallbackHandler().ServeHTTP(recorder, httptest.NewRequest("POST", defaultEventsURL, strings.NewReader("not json")))
+	assert.Equal(t, "failed to decode the event\n", recorder.Body.String())
+	assert.Equal(t, http.StatusMethodNotAllowed, post("GET", ""))
+}
+
+func TestCallbackServerAddresses(t *testing.T) {
+	serverCert := newTestCertificate(t, "callbacks", nil)
+	cert, err := tls.X509KeyPair(serverCert.certPEM, serverCert.keyPEM)
+	require.NoError(t, err)
+
+	cases := []struct {
+		config Config
+		scheme string
+		host   string
+	}{
+		{Config{EventsListenAddress: "127.0.0.1:0"}, "http", "127.0.0.1"},
+		{Config{EventsListenAddress: "127.0.0.1:0", EventsAdvertiseAddress: "callbacks.example.com"}, "http", "callbacks.example.com"},
+		{
+			Config{
+				EventsListenAddress: "127.0.0.1:0",
+				EventsTLSConfig:     &tls.Config{Certificates: []tls.Certificate{cert}},
+			},
+			"https", "127.0.0.1",
+		},
+	}
+	for _, c := range cases {
+		c.config.URL = "http://127.0.0.1:0"
+		marathon, err := NewClient(c.config)
+		require.NoError(t, err)
+		client := marathon.(*marathonClient)
+		require.NoError(t, client.startCallbackServer())
+
+		// step: the port picked is advertised
+		_, port, err := net.SplitHostPort(client.callbackAddress)
+		require.NoError(t, err)
+		assert.NotEqual(t, "0", port)
+		assert.Equal(t, fmt.Sprintf("%s://%s/event", c.scheme, net.JoinHostPort(c.host, port)), client.SubscriptionURL())
+
+		httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+		response, err := httpClient.Post(fmt.Sprintf("%s://127.0.0.1:%s/event", c.scheme, port), "application/json",
+			strings.NewReader(testCases[0].source))
+		require.NoError(t, err)
+		response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+
+		require.NoError(t, client.Close(context.Background()))
+	}
+}
+
+func TestCallbackExternalServer(t *testing.T) {
+	clientCfg := NewDefaultConfig()
+	clientCfg.EventsExternalServer = true
+	config := configContainer{
+		client: &clientCfg,
+		server: &serverConfig{scope: "callback-lifecycle"},
+	}
+	endpoint := newFakeMarathonEndpoint(t, &config)
+	defer endpoint.Close()
+
+	// step: the callback URL is required
+	_, err := endpoint.Client.AddEventsListener(EventIDApplications)
+	assert.Error(t, err)
+
+	client := endpoint.Client.(*marathonClient)
+	client.config.CallbackURL = "http://127.0.0.1:10101"
+	events, err := client.AddEventsListener(EventIDApplications)
+	require.NoError(t, err)
+	assert.Nil(t, client.eventsHTTP)
+
+	mux := http.NewServeMux()
+	mux.Handle(defaultEventsURL, client.CallbackHandler())
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	response, err := http.Post(server.URL+defaultEventsURL, "application/json", strings.NewReader(testCases[0].source))
+	require.NoError(t, err)
+	response.Body.Close()
+
+	select {
+	case event := <-events:
+		assert.Equal(t, testCases[0].name, event.Name)
+	case <-time.After(eventPublishTimeout):
+		assert.Fail(t, "did not receive event in time")
+	}
+	require.NoError(t, client.Close(context.Background()))
+}
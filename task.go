@@ -17,6 +17,7 @@ limitations under the License.
 package marathon
 
 import (
+	"context"
 	"fmt"
 	"strings"
 )
@@ -80,13 +81,18 @@ func (r *Task) HasHealthCheckResults() bool {
 // AllTasks lists tasks of all applications.
 //		opts: 		AllTasksOpts request payload
 func (r *marathonClient) AllTasks(opts *AllTasksOpts) (*Tasks, error) {
+	return r.AllTasksContext(context.Background(), opts)
+}
+
+// AllTasksContext is like AllTasks but uses the given context
+func (r *marathonClient) AllTasksContext(ctx context.Context, opts *AllTasksOpts) (*Tasks, error) {
 	path, err := addOptions(marathonAPITasks, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	tasks := new(Tasks)
-	if err := r.apiGet(path, nil, tasks); err != nil {
+	if err := r.apiGet(ctx, path, nil, tasks); err != nil {
 		return nil, err
 	}
 
@@ -96,8 +102,13 @@ func (r *marathonClient) AllTasks(opts *AllTasksOpts) (*Tasks, error) {
 // Tasks retrieves a list of tasks for an application
 //		id:		the id of the application
 func (r *marathonClient) Tasks(id string) (*Tasks, error) {
+	return r.TasksContext(context.Background(), id)
+}
+
+// TasksContext is like Tasks but uses the given context
+func (r *marathonClient) TasksContext(ctx context.Context, id string) (*Tasks, error) {
 	tasks := new(Tasks)
-	if err := r.apiGet(fmt.Sprintf("%s/%s/tasks", marathonAPIApps, trimRootPath(id)), nil, tasks); err != nil {
+	if err := r.apiGet(ctx, fmt.Sprintf("%s/%s/tasks", marathonAPIApps, trimRootPath(id)), nil, tasks); err != nil {
 		return nil, err
 	}
 
@@ -108,6 +119,11 @@ func (r *marathonClient) Tasks(id string) (*Tasks, error) {
 //		id:		the id of the application
 //		opts: 		KillApplicationTasksOpts request payload
 func (r *marathonClient) KillApplicationTasks(id string, opts *KillApplicationTasksOpts) (*Tasks, error) {
+	return r.KillApplicationTasksContext(context.Background(), id, opts)
+}
+
+// KillApplicationTasksContext is like KillApplicationTasks but uses the given context
+func (r *marathonClient) KillApplicationTasksContext(ctx context.Context, id string, opts *KillApplicationTasksOpts) (*Tasks, error) {
 	path := fmt.Sprintf("%s/%s/tasks", marathonAPIApps, trimRootPath(id))
 	path, err := addOptions(path, opts)
 	if err != nil {
@@ -115,7 +131,7 @@ func (r *marathonClient) KillApplicationTasks(id string, opts *KillApplicationTa
 	}
 
 	tasks := new(Tasks)
-	if err := r.apiDelete(path, nil, tasks); err != nil {
+	if err := r.apiDelete(ctx, path, nil, tasks); err != nil {
 		return nil, err
 	}
 
@@ -126,6 +142,11 @@ func (r *marathonClient) KillApplicationTasks(id string, opts *KillApplicationTa
 // 	taskID:		the id for the task
 //	opts:		KillTaskOpts request payload
 func (r *marathonClient) KillTask(taskID string, opts *KillTaskOpts) (*Task, error) {
+	return r.KillTaskContext(context.Background(), taskID, opts)
+}
+
+// KillTaskContext is like KillTask but uses the given context
+func (r *marathonClient) KillTaskContext(ctx context.Context, taskID string, opts *KillTaskOpts) (*Task, error) {
 	appName := taskID[0:strings.LastIndex(taskID, ".")]
 	appName = strings.Replace(appName, "_", "/", -1)
 	taskID = strings.Replace(taskID, "/", "_", -1)
@@ -140,7 +161,7 @@ func (r *marathonClient) KillTask(taskID string, opts *KillTaskOpts) (*Task, err
 		Task Task `json:"task"`
 	})
 
-	if err := r.apiDelete(path, nil, wrappedTask); err != nil {
+	if err := r.apiDelete(ctx, path, nil, wrappedTask); err != nil {
 		return nil, err
 	}
 
@@ -151,6 +172,11 @@ func (r *marathonClient) KillTask(taskID string, opts *KillTaskOpts) (*Task, err
 //	tasks:		the array of task ids
 //	opts:		KillTaskOpts request payload
 func (r *marathonClient) KillTasks(tasks []string, opts *KillTaskOpts) error {
+	return r.KillTasksContext(context.Background(), tasks, opts)
+}
+
+// KillTasksContext is like KillTasks but uses the given context
+func (r *marathonClient) KillTasksContext(ctx context.Context, tasks []string, opts *KillTaskOpts) error {
 	path := fmt.Sprintf("%s/delete", marathonAPITasks)
 	path, err := addOptions(path, opts)
 	if err != nil {
@@ -162,7 +188,7 @@ func (r *marathonClient) KillTasks(tasks []string, opts *KillTaskOpts) error {
 	}
 	post.IDs = tasks
 
-	return r.ApiPost(path, &post, nil)
+	return r.apiPost(ctx, path, &post, nil)
 }
 
 // TaskEndpoints gets the endpoints i.e. HOST_IP:DYNAMIC_PORT for a specific application service
@@ -178,8 +204,13 @@ func (r *marathonClient) KillTasks(tasks []string, opts *KillTaskOpts) error {
 //		port:		the container port you are interested in
 //		health: 	whether to check the health or not
 func (r *marathonClient) TaskEndpoints(name string, port int, healthCheck bool) ([]string, error) {
+	return r.TaskEndpointsContext(context.Background(), name, port, healthCheck)
+}
+
+// TaskEndpointsContext is like TaskEndpoints but uses the given context
+func (r *marathonClient) TaskEndpointsContext(ctx context.Context, name string, port int, healthCheck bool) ([]string, error) {
 	// step: get the application details
-	application, err := r.Application(name)
+	application, err := r.ApplicationContext(ctx, name)
 	if err != nil {
 		return nil, err
 	}
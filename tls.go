@@ -0,0 +1,215 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TLSConfig is the TLS configuration of the connections to the Marathon cluster, applied to
+// the API requests, the event stream and the health checks alike
+type TLSConfig struct {
+	// CAFile is the path of a PEM bundle of the certificate authorities to trust instead
+	// of the system ones
+	CAFile string
+	// CAPEM is a PEM bundle of certificate authorities to trust, in addition to CAFile
+	CAPEM []byte
+	// CertFile and KeyFile are the paths of the PEM encoded client certificate and key. They
+	// are loaded again whenever they change on disk.
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the name the certificates of the members are verified against
+	ServerName string
+	// PinnedPublicKeys are the base64 encoded SHA-256 hashes of the subject public key info
+	// of certificates, optionally prefixed with "sha256//". When set, the certificate chain
+	// of a member must contain one of them.
+	PinnedPublicKeys []string
+}
+
+// build creates the tls.Config of the configuration
+func (t *TLSConfig) build() (*tls.Config, error) {
+	config := &tls.Config{ServerName: t.ServerName}
+
+	// step: load the certificate authorities
+	if t.CAFile != "" || len(t.CAPEM) > 0 {
+		pool := x509.NewCertPool()
+		if t.CAFile != "" {
+			content, err := ioutil.ReadFile(t.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the CA file: %s", err)
+			}
+			if !pool.AppendCertsFromPEM(content) {
+				return nil, fmt.Errorf("no certificate found in the CA file: %s", t.CAFile)
+			}
+		}
+		if len(t.CAPEM) > 0 && !pool.AppendCertsFromPEM(t.CAPEM) {
+			return nil, errors.New("no certificate found in the CA PEM")
+		}
+		config.RootCAs = pool
+	}
+
+	// step: load the client certificate, checking it is valid right away
+	if t.CertFile != "" || t.KeyFile != "" {
+		reloader := &certificateReloader{certFile: t.CertFile, keyFile: t.KeyFile}
+		if _, err := reloader.certificate(); err != nil {
+			return nil, err
+		}
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return reloader.certificate()
+		}
+	}
+
+	// step: check the public key pins
+	if len(t.PinnedPublicKeys) > 0 {
+		pins := make(map[string]bool, len(t.PinnedPublicKeys))
+		for _, pin := range t.PinnedPublicKeys {
+			pins[strings.TrimPrefix(pin, "sha256//")] = true
+		}
+		config.VerifyPeerCertificate = func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+			return verifyPinnedPublicKeys(pins, verifiedChains)
+		}
+	}
+
+	return config, nil
+}
+
+// certificateReloader loads a client certificate again whenever its files change
+type certificateReloader struct {
+	sync.Mutex
+	certFile string
+	keyFile  string
+	cert     *tls.Certificate
+	modTime  time.Time
+}
+
+// certificate returns the current certificate, reloading it if the files were modified
+func (r *certificateReloader) certificate() (*tls.Certificate, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	modTime, err := latestModTime(r.certFile, r.keyFile)
+	if err != nil {
+		if r.cert != nil {
+			// step: keep using the current certificate while the files are being replaced
+			return r.cert, nil
+		}
+		return nil, fmt.Errorf("failed to read the client certificate: %s", err)
+	}
+	if r.cert != nil && modTime.Equal(r.modTime) {
+		return r.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		if r.cert != nil {
+			return r.cert, nil
+		}
+		return nil, fmt.Errorf("failed to load the client certificate: %s", err)
+	}
+	r.cert = &cert
+	r.modTime = modTime
+
+	return r.cert, nil
+}
+
+// latestModTime returns the latest modification time of the files
+func latestModTime(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// verifyPinnedPublicKeys checks that a certificate of the verified chains has a pinned public key
+func verifyPinnedPublicKeys(pins map[string]bool, verifiedChains [][]*x509.Certificate) error {
+	for _, chain := range verifiedChains {
+		for _, cert := range chain {
+			hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			if pins[base64.StdEncoding.EncodeToString(hash[:])] {
+				return nil
+			}
+		}
+	}
+	return errors.New("no pinned public key found in the certificate chain")
+}
+
+// withTLSConfig returns a copy of the HTTP client using the TLS configuration
+func withTLSConfig(client *http.Client, config *tls.Config) (*http.Client, error) {
+	var transport *http.Transport
+	switch base := client.Transport.(type) {
+	case nil:
+		transport = &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		}
+	case *http.Transport:
+		if base.TLSClientConfig != nil || base.DialTLS != nil {
+			return nil, errors.New("the TLS configuration conflicts with the TLS settings of the HTTP client transport")
+		}
+		transport = &http.Transport{
+			Proxy:                  base.Proxy,
+			DialContext:            base.DialContext,
+			Dial:                   base.Dial,
+			TLSHandshakeTimeout:    base.TLSHandshakeTimeout,
+			DisableKeepAlives:      base.DisableKeepAlives,
+			DisableCompression:     base.DisableCompression,
+			MaxIdleConns:           base.MaxIdleConns,
+			MaxIdleConnsPerHost:    base.MaxIdleConnsPerHost,
+			IdleConnTimeout:        base.IdleConnTimeout,
+			ResponseHeaderTimeout:  base.ResponseHeaderTimeout,
+			ExpectContinueTimeout:  base.ExpectContinueTimeout,
+			ProxyConnectHeader:     base.ProxyConnectHeader,
+			MaxResponseHeaderBytes: base.MaxResponseHeaderBytes,
+		}
+	default:
+		return nil, fmt.Errorf("the TLS configuration can not be applied to an HTTP client transport of type %T", base)
+	}
+	transport.TLSClientConfig = config
+
+	return &http.Client{
+		Transport:     transport,
+		CheckRedirect: client.CheckRedirect,
+		Jar:           client.Jar,
+		Timeout:       client.Timeout,
+	}, nil
+}
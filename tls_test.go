@@ -0,0 +1,248 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCertificate is a certificate and its key used in the TLS tests
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *rsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCertificate creates a certificate signed by the parent, or a self signed CA when the parent is nil
+func newTestCertificate(t *testing.T, name string, parent *testCertificate, dnsNames ...string) *testCertificate {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     dnsNames,
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+	}
+}
+
+// writeFiles writes the certificate and key to the files
+func (c *testCertificate) writeFiles(t *testing.T, certFile, keyFile string, modTime time.Time) {
+	require.NoError(t, ioutil.WriteFile(certFile, c.certPEM, 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile, c.keyPEM, 0600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+}
+
+// newMutualTLSServer starts a server requiring client certificates signed by the CA, responding
+// with the common name of the client certificate
+func newMutualTLSServer(t *testing.T, ca, serverCert *testCertificate) *httptest.Server {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	cert, err := tls.X509KeyPair(serverCert.certPEM, serverCert.keyPEM)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	server.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	server.StartTLS()
+	return server
+}
+
+// ping calls the server through the HTTP client of the marathon client and returns the response
+func ping(t *testing.T, client Marathon, url string) (string, error) {
+	response, err := client.(*marathonClient).config.HTTPClient.Get(url + "/ping")
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	content, err := ioutil.ReadAll(response.Body)
+	require.NoError(t, err)
+	return string(content), nil
+}
+
+func TestTLSConfig(t *testing.T) {
+	ca := newTestCertificate(t, "ca", nil)
+	server := newMutualTLSServer(t, ca, newTestCertificate(t, "server", ca, "marathon.internal"))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "go-marathon-tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	newTestCertificate(t, "first", ca).writeFiles(t, certFile, keyFile, time.Now().Add(-time.Minute))
+
+	client, err := NewClient(Config{
+		URL: server.URL,
+		TLS: &TLSConfig{
+			CAPEM:      ca.certPEM,
+			CertFile:   certFile,
+			KeyFile:    keyFile,
+			ServerName: "marathon.internal",
+		},
+	})
+	require.NoError(t, err)
+	config := client.(*marathonClient).config
+	assert.Equal(t, config.HTTPClient.Transport.(*http.Transport).TLSClientConfig,
+		config.HTTPSSEClient.Transport.(*http.Transport).TLSClientConfig)
+	assert.Equal(t, defaultHTTPClient.Timeout, config.HTTPClient.Timeout)
+
+	name, err := ping(t, client, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "first", name)
+	_, err = client.Ping()
+	assert.NoError(t, err)
+
+	// step: a renewed certificate is used for the next connections
+	newTestCertificate(t, "second", ca).writeFiles(t, certFile, keyFile, time.Now())
+	config.HTTPClient.Transport.(*http.Transport).CloseIdleConnections()
+	name, err = ping(t, client, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "second", name)
+}
+
+func TestTLSConfigServerVerification(t *testing.T) {
+	ca := newTestCertificate(t, "ca", nil)
+	serverCert := newTestCertificate(t, "server", ca, "marathon.internal")
+	server := newMutualTLSServer(t, ca, serverCert)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "go-marathon-tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	newTestCertificate(t, "client", ca).writeFiles(t, certFile, keyFile, time.Now())
+	caFile := filepath.Join(dir, "ca.crt")
+	require.NoError(t, ioutil.WriteFile(caFile, ca.certPEM, 0600))
+
+	serverPin := sha256.Sum256(serverCert.cert.RawSubjectPublicKeyInfo)
+	cases := []struct {
+		name  string
+		tls   TLSConfig
+		valid bool
+	}{
+		{
+			name:  "matching server name",
+			tls:   TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile, ServerName: "marathon.internal"},
+			valid: true,
+		},
+		{
+			name: "wrong server name",
+			tls:  TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile, ServerName: "marathon.external"},
+		},
+		{
+			name: "no client certificate",
+			tls:  TLSConfig{CAFile: caFile, ServerName: "marathon.internal"},
+		},
+		{
+			name: "untrusted server",
+			tls:  TLSConfig{CertFile: certFile, KeyFile: keyFile, ServerName: "marathon.internal"},
+		},
+		{
+			name: "pinned server key",
+			tls: TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile, ServerName: "marathon.internal",
+				PinnedPublicKeys: []string{"sha256//" + base64.StdEncoding.EncodeToString(serverPin[:])}},
+			valid: true,
+		},
+		{
+			name: "other pinned key",
+			tls: TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile, ServerName: "marathon.internal",
+				PinnedPublicKeys: []string{base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))}},
+		},
+	}
+	for _, x := range cases {
+		tlsConfig := x.tls
+		client, err := NewClient(Config{URL: server.URL, TLS: &tlsConfig})
+		require.NoError(t, err, x.name)
+		_, err = ping(t, client, server.URL)
+		if x.valid {
+			assert.NoError(t, err, x.name)
+		} else {
+			assert.Error(t, err, x.name)
+		}
+	}
+}
+
+func TestTLSConfigInvalid(t *testing.T) {
+	_, err := NewClient(Config{URL: "https://127.0.0.1:8443", TLS: &TLSConfig{CAPEM: []byte("garbage")}})
+	assert.Error(t, err)
+	_, err = NewClient(Config{URL: "https://127.0.0.1:8443", TLS: &TLSConfig{CertFile: "/does/not/exist", KeyFile: "/does/not/exist"}})
+	assert.Error(t, err)
+
+	custom := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{}}}
+	_, err = NewClient(Config{URL: "https://127.0.0.1:8443", HTTPClient: custom, TLS: &TLSConfig{}})
+	assert.Error(t, err)
+
+	// step: the transport of the client is kept otherwise
+	dial := (&net.Dialer{Timeout: time.Second}).DialContext
+	custom = &http.Client{Transport: &http.Transport{DialContext: dial, MaxIdleConnsPerHost: 7}}
+	client, err := NewClient(Config{URL: "https://127.0.0.1:8443", HTTPClient: custom, TLS: &TLSConfig{ServerName: "marathon"}})
+	require.NoError(t, err)
+	transport := client.(*marathonClient).config.HTTPClient.Transport.(*http.Transport)
+	assert.Equal(t, 7, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, "marathon", transport.TLSClientConfig.ServerName)
+	assert.Nil(t, custom.Transport.(*http.Transport).TLSClientConfig)
+}
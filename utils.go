@@ -23,26 +23,10 @@ import (
 	"net/url"
 	"reflect"
 	"strings"
-	"sync/atomic"
-	"time"
 
 	"github.com/google/go-querystring/query"
 )
 
-type atomicSwitch int64
-
-func (r *atomicSwitch) IsSwitched() bool {
-	return atomic.LoadInt64((*int64)(r)) != 0
-}
-
-func (r *atomicSwitch) SwitchOn() {
-	atomic.StoreInt64((*int64)(r), 1)
-}
-
-func (r *atomicSwitch) SwitchedOff() {
-	atomic.StoreInt64((*int64)(r), 0)
-}
-
 func validateID(id string) string {
 	if !strings.HasPrefix(id, "/") {
 		return fmt.Sprintf("/%s", id)
@@ -57,26 +41,6 @@ func trimRootPath(id string) string {
 	return id
 }
 
-func deadline(timeout time.Duration, work func(chan bool) error) error {
-	result := make(chan error)
-	timer := time.After(timeout)
-	stopChannel := make(chan bool, 1)
-
-	// allow the method to attempt
-	go func() {
-		result <- work(stopChannel)
-	}()
-	for {
-		select {
-		case err := <-result:
-			return err
-		case <-timer:
-			stopChannel <- true
-			return ErrTimeoutError
-		}
-	}
-}
-
 func getInterfaceAddress(name string) (string, error) {
 	interfaces, err := net.Interfaces()
 	if err != nil {
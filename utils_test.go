@@ -19,7 +19,6 @@ package marathon
 import (
 	"net"
 	"testing"
-	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -36,38 +35,6 @@ func (sa stubAddr) String() string {
 	return sa.addr + "/8"
 }
 
-func TestUtilsAtomicIsSwitched(t *testing.T) {
-	var sw atomicSwitch
-	assert.False(t, sw.IsSwitched())
-	sw.SwitchOn()
-	assert.True(t, sw.IsSwitched())
-}
-
-func TestUtilsAtomicIsSwitchedOff(t *testing.T) {
-	var sw atomicSwitch
-	assert.False(t, sw.IsSwitched())
-	sw.SwitchOn()
-	assert.True(t, sw.IsSwitched())
-	sw.SwitchedOff()
-	assert.False(t, sw.IsSwitched())
-}
-
-func TestUtilsDeadline(t *testing.T) {
-	err := deadline(time.Duration(5)*time.Millisecond, func(chan bool) error {
-		<-time.After(time.Duration(1) * time.Second)
-		return nil
-	})
-	assert.Error(t, err)
-	assert.Equal(t, ErrTimeoutError, err)
-
-	err = deadline(time.Duration(5)*time.Second, func(chan bool) error {
-		<-time.After(time.Duration(5) * time.Millisecond)
-		return nil
-	})
-
-	assert.NoError(t, err)
-}
-
 func TestUtilsContains(t *testing.T) {
 	list := []string{"1", "2", "3"}
 	assert.True(t, contains(list, "2"))
@@ -0,0 +1,203 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// eventWaiter wakes a wait up on the events about the object it waits on
+type eventWaiter struct {
+	sync.Mutex
+	// the event types the wait needs to do without polling
+	filter int
+	// reports whether the event is about the object
+	matches func(event *Event) bool
+	// the deployments of the object, failing the wait when they fail
+	deployments map[string]bool
+	// the failure of a deployment of the object
+	err error
+	// signalled on the events about the object
+	wake chan struct{}
+}
+
+// newEventWaiter creates a waiter of the events of the filter matching the predicate
+func newEventWaiter(filter int, matches func(event *Event) bool, deployments ...string) *eventWaiter {
+	waiter := &eventWaiter{
+		filter:      filter | EventIDDeploymentSuccess | EventIDDeploymentFailed,
+		matches:     matches,
+		deployments: make(map[string]bool),
+		wake:        make(chan struct{}, 1),
+	}
+	waiter.addDeployments(deployments...)
+	return waiter
+}
+
+// matchEventAppID returns a predicate matching the events about the application or pod
+func matchEventAppID(id string) func(event *Event) bool {
+	id = normalizeEventID(id)
+	return func(event *Event) bool {
+		for _, eventAppID := range eventAppIDs(event) {
+			if normalizeEventID(eventAppID) == id {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// addDeployments adds deployments of the object, a waiter may be nil
+func (w *eventWaiter) addDeployments(ids ...string) {
+	if w == nil {
+		return
+	}
+	w.Lock()
+	defer w.Unlock()
+	for _, id := range ids {
+		w.deployments[id] = true
+	}
+}
+
+// failure returns the failure of a deployment of the object, if any
+func (w *eventWaiter) failure() error {
+	w.Lock()
+	defer w.Unlock()
+	return w.err
+}
+
+// notify wakes the wait up if the event is about the object
+func (w *eventWaiter) notify(event *Event) {
+	w.Lock()
+	defer w.Unlock()
+
+	switch e := event.Event.(type) {
+	case *EventDeploymentFailed:
+		if !w.deployments[e.ID] {
+			return
+		}
+		w.err = &DeploymentFailedError{ID: e.ID}
+	case *EventDeploymentSuccess:
+		if !w.deployments[e.ID] && !w.matches(event) {
+			return
+		}
+	default:
+		if !w.matches(event) {
+			return
+		}
+		// step: the deployments acting on the object are tracked for their failure
+		if plan := eventDeploymentPlan(event); plan != nil && plan.ID != "" {
+			w.deployments[plan.ID] = true
+		}
+	}
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// eventDeploymentPlan returns the plan of a deployment event, if any
+func eventDeploymentPlan(event *Event) *DeploymentPlan {
+	switch e := event.Event.(type) {
+	case *EventDeploymentInfo:
+		return e.Plan
+	case *EventDeploymentStepSuccess:
+		return e.Plan
+	case *EventDeploymentStepFailure:
+		return e.Plan
+	}
+	return nil
+}
+
+// wait calls the check until it is done, fails, times out or the context is done. The check runs
+// every PollingWaitTime, or when the events wake the waiter up and every EventsPollingWaitTime
+// while the event subscription receives the events the waiter needs.
+func (r *marathonClient) wait(ctx context.Context, timeout time.Duration, waiter *eventWaiter, check func(context.Context) (bool, error)) error {
+	r.Lock()
+	r.waiters[waiter] = true
+	r.Unlock()
+	defer func() {
+		r.Lock()
+		delete(r.waiters, waiter)
+		r.Unlock()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	ticker := time.NewTicker(r.config.PollingWaitTime)
+	defer ticker.Stop()
+	for {
+		if done, err := check(ctx); err != nil || done {
+			// step: the object may be done as its deployment failed, e.g. a deployment is gone
+			// as well when it fails
+			if err == nil {
+				err = waiter.failure()
+			}
+			return err
+		}
+		checked := time.Now()
+
+	next:
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-timer.C:
+				return ErrTimeoutError
+			case <-waiter.wake:
+				if err := waiter.failure(); err != nil {
+					return err
+				}
+				break next
+			case <-ticker.C:
+				if !r.receivesEvents(waiter.filter) || time.Since(checked) >= r.config.EventsPollingWaitTime {
+					break next
+				}
+			}
+		}
+	}
+}
+
+// receivesEvents reports whether the event subscription receives the events of the filter
+func (r *marathonClient) receivesEvents(filter int) bool {
+	r.RLock()
+	defer r.RUnlock()
+
+	switch r.config.EventsTransport {
+	case EventsTransportSSE:
+		if !r.streamStatus.Connected {
+			return false
+		}
+		wanted := r.eventStreamTypes()
+		if len(wanted) == 0 {
+			return true
+		}
+		received := 0
+		for _, eventType := range wanted {
+			id, _ := eventTypeID(eventType)
+			received |= id
+		}
+		return received&filter == filter
+	case EventsTransportCallback:
+		// note: the callbacks are not filtered, they are subscribed with the first listener
+		return len(r.listeners) > 0 && (r.eventsHTTP != nil || r.config.EventsExternalServer)
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2019 The go-marathon Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marathon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitServer serves a deployment and an application whose deployment is in progress until done
+type waitServer struct {
+	*httptest.Server
+	// set once the deployment is done
+	done int32
+	// the number of requests served
+	requests int32
+}
+
+func newWaitServer() *waitServer {
+	server := new(waitServer)
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&server.requests, 1)
+		done := atomic.LoadInt32(&server.done) == 1
+		switch r.URL.Path {
+		case "/v2/deployments":
+			if done {
+				w.Write([]byte(`[]`))
+			} else {
+				w.Write([]byte(`[{"id": "deployment-1", "affectedApps": ["/app"], "steps": []}]`))
+			}
+		case "/v2/apps/app":
+			if done {
+				w.Write([]byte(`{"app": {"id": "/app", "instances": 1, "tasksRunning": 1, "tasks": [{"id": "task-1"}]}}`))
+			} else {
+				w.Write([]byte(`{"app": {"id": "/app", "instances": 1, "tasksRunning": 0, "deployments": [{"id": "deployment-1"}]}}`))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server
+}
+
+func newWaitClient(t *testing.T, server *waitServer, streaming bool) *marathonClient {
+	config := NewDefaultConfig()
+	config.URL = server.URL
+	config.EventsTransport = EventsTransportSSE
+	config.PollingWaitTime = 10 * time.Millisecond
+	config.EventsPollingWaitTime = 200 * time.Millisecond
+	client, err := NewClient(config)
+	require.NoError(t, err)
+
+	// step: pretend the event stream is connected, receiving all events
+	marathon := client.(*marathonClient)
+	marathon.Lock()
+	marathon.streamStatus.Connected = streaming
+	marathon.Unlock()
+	return marathon
+}
+
+// dispatchAfter hands the event to the client once the wait is registered
+func dispatchAfter(t *testing.T, client *marathonClient, event string) {
+	go func() {
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			client.RLock()
+			waiting := len(client.waiters) > 0
+			client.RUnlock()
+			if waiting {
+				time.Sleep(20 * time.Millisecond)
+				assert.NoError(t, client.handleEvent(event))
+				return
+			}
+		}
+	}()
+}
+
+func TestWaitOnDeploymentFailed(t *testing.T) {
+	server := newWaitServer()
+	defer server.Close()
+	client := newWaitClient(t, server, false)
+
+	dispatchAfter(t, client, `{"eventType": "deployment_failed", "id": "deployment-1"}`)
+	err := client.WaitOnDeployment("deployment-1", time.Second)
+	if assert.IsType(t, &DeploymentFailedError{}, err) {
+		assert.Equal(t, "deployment-1", err.(*DeploymentFailedError).ID)
+	}
+
+	// step: the failures of other deployments are ignored
+	dispatchAfter(t, client, `{"eventType": "deployment_failed", "id": "deployment-2"}`)
+	assert.Equal(t, ErrTimeoutError, client.WaitOnDeployment("deployment-1", 100*time.Millisecond))
+}
+
+func TestWaitOnDeploymentGoneAfterFailure(t *testing.T) {
+	server := newWaitServer()
+	defer server.Close()
+	client := newWaitClient(t, server, true)
+
+	// step: the failure arrives before the check finds the deployment gone
+	waiter := newEventWaiter(0, func(*Event) bool { return false }, "deployment-1")
+	waiter.notify(&Event{Event: &EventDeploymentFailed{ID: "deployment-1"}})
+	<-waiter.wake
+	err := client.wait(context.Background(), time.Second, waiter, func(context.Context) (bool, error) {
+		return true, nil
+	})
+	assert.IsType(t, &DeploymentFailedError{}, err)
+}
+
+func TestWaitOnApplicationDeploymentFailed(t *testing.T) {
+	server := newWaitServer()
+	defer server.Close()
+	client := newWaitClient(t, server, true)
+
+	// step: the deployment of the application is learnt from the application
+	dispatchAfter(t, client, `{"eventType": "deployment_failed", "id": "deployment-1"}`)
+	err := client.WaitOnApplication("/app", time.Second)
+	assert.IsType(t, &DeploymentFailedError{}, err)
+}
+
+func TestWaitOnDeploymentEvents(t *testing.T) {
+	server := newWaitServer()
+	defer server.Close()
+	client := newWaitClient(t, server, true)
+
+	// step: while the events are received, the API is polled less often
+	result := make(chan error, 1)
+	go func() {
+		result <- client.WaitOnDeployment("deployment-1", time.Second)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, atomic.LoadInt32(&server.requests) < 3, "the API is polled while events are received")
+
+	// step: the wait completes on the success of the deployment
+	atomic.StoreInt32(&server.done, 1)
+	require.NoError(t, client.handleEvent(`{"eventType": "deployment_success", "id": "deployment-1"}`))
+	select {
+	case err := <-result:
+		assert.NoError(t, err)
+	case <-time.After(50 * time.Millisecond):
+		assert.Fail(t, "the wait did not complete on the deployment success")
+	}
+
+	// step: the API is still polled as a fallback
+	atomic.StoreInt32(&server.done, 0)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		atomic.StoreInt32(&server.done, 1)
+	}()
+	begin := time.Now()
+	assert.NoError(t, client.WaitOnApplication("/app", time.Second))
+	assert.True(t, time.Since(begin) >= 150*time.Millisecond, fmt.Sprintf("polled after %s", time.Since(begin)))
+}